@@ -2,24 +2,266 @@ package arguments
 
 import (
 	"flag"
+	"io/ioutil"
+	"strings"
 )
 
-var (
-	fakeNameFlag = flag.String(
+// Flags are the flags of a counterfeiter invocation.
+type Flags struct {
+	FakeName     string   // the name of the fake struct
+	OutputPath   string   // the file or directory to write the fake to
+	PackageMode  bool     // generate an interface and shim for a package
+	ShimTypes    []string // in package mode, the struct types to also shim
+	Vars         []string // in package mode, the variables and constants to access, or *
+	ExcludeVars  []string // in package mode, the variables and constants not to access
+	FromStruct   string   // the struct type to extract the interface from
+	Adapter      bool     // with FromStruct, also generate an adapter
+	Check        bool     // only check that the fake on disk is up to date
+	Config       string   // the manifest declaring the fakes to generate
+	OutputFormat string   // the format of the report, text or json
+	ParamNames   bool     // use the parameter names from the source
+	DeepCopyArgs bool     // record deep copies of the arguments
+	Strict       bool     // fail calls that nothing is stubbed for
+	Expectations bool     // generate ExpectX and Verify
+	SyncHooks    bool     // generate XBlockUntil, XWaitForCalls and XCallEvents
+	RecordCalls  bool     // generate XCallRecords and XResultsForCall
+	InjectErrors bool     // generate XFailsWith, XFailsOnCall, FailAll and FailRandomly
+	CallRecorder bool     // generate SetCallRecorder
+	ReturnsWhen  bool     // generate XReturnsWhen, XReturnsWhenMatches and XReturnsWhenStrict
+	Sequences    bool     // generate XReturnsSequence
+	Reset        bool     // generate Reset, ResetCalls and XReset
+	Delegate     bool     // generate NewXWithDelegate
+	ContextErrs  bool     // generate ReturnContextErrors
+	All          bool     // fake every exported interface of the package
+	Match        string   // with All, the pattern of the interfaces to fake
+	Template     string   // the template file or directory to generate the fake with
+	Style        string   // the style of test double to generate, testify or gomock
+}
+
+// NewFlagSet returns a FlagSet that parses the counterfeiter flags into flags,
+// which are set to their defaults.
+func NewFlagSet(flags *Flags, errorHandling flag.ErrorHandling) *flag.FlagSet {
+	fs := flag.NewFlagSet("counterfeiter", errorHandling)
+	fs.StringVar(
+		&flags.FakeName,
 		"fake-name",
 		"",
 		"The name of the fake struct",
 	)
 
-	outputPathFlag = flag.String(
+	fs.StringVar(
+		&flags.OutputPath,
 		"o",
 		"",
 		"The file or directory to which the generated fake will be written",
 	)
 
-	packageFlag = flag.Bool(
+	fs.BoolVar(
+		&flags.PackageMode,
 		"p",
 		false,
 		"whether or not to generate a package shim",
 	)
-)
+
+	flags.ShimTypes = nil
+	fs.Var(
+		(*listValue)(&flags.ShimTypes),
+		"shim-types",
+		"In package mode, a comma separated list of exported struct types to also generate an interface and shim for",
+	)
+
+	flags.Vars = nil
+	fs.Var(
+		(*listValue)(&flags.Vars),
+		"vars",
+		"In package mode, a comma separated list of exported variables and constants to also generate accessor methods for, or * for all of them",
+	)
+
+	flags.ExcludeVars = nil
+	fs.Var(
+		(*listValue)(&flags.ExcludeVars),
+		"exclude-vars",
+		"In package mode, a comma separated list of variables and constants to leave out of --vars",
+	)
+
+	fs.StringVar(
+		&flags.FromStruct,
+		"from-struct",
+		"",
+		"The struct type, such as sdk.Client, to extract an interface named by the argument from and fake",
+	)
+
+	fs.BoolVar(
+		&flags.Adapter,
+		"adapter",
+		false,
+		"whether or not to also generate an adapter for the interface extracted with --from-struct",
+	)
+
+	fs.BoolVar(
+		&flags.Check,
+		"check",
+		false,
+		"whether or not to only check that the fake on disk is up to date, instead of writing it",
+	)
+
+	fs.StringVar(
+		&flags.Config,
+		"config",
+		"",
+		"The counterfeiter.yaml manifest declaring the fakes to generate",
+	)
+
+	fs.StringVar(
+		&flags.OutputFormat,
+		"output-format",
+		"text",
+		"The format of the report of what was generated, text or json",
+	)
+
+	fs.BoolVar(
+		&flags.ParamNames,
+		"param-names",
+		false,
+		"whether or not to use the parameter names from the source in the generated code",
+	)
+
+	fs.BoolVar(
+		&flags.DeepCopyArgs,
+		"deep-copy-args",
+		false,
+		"whether or not the fake records deep copies of the maps, slices, arrays and pointers it is called with",
+	)
+
+	fs.BoolVar(
+		&flags.Strict,
+		"strict",
+		false,
+		"whether or not the fake fails calls to methods that nothing is stubbed for",
+	)
+
+	fs.BoolVar(
+		&flags.Expectations,
+		"expectations",
+		false,
+		"whether or not to generate ExpectX methods declaring the calls the fake expects, and Verify to check them",
+	)
+
+	fs.BoolVar(
+		&flags.SyncHooks,
+		"sync-hooks",
+		false,
+		"whether or not to generate XBlockUntil, XWaitForCalls and XCallEvents for testing concurrent callers",
+	)
+
+	fs.BoolVar(
+		&flags.RecordCalls,
+		"record-calls",
+		false,
+		"whether or not the fake keeps a record of the results, timing and panic of each call, for XCallRecords and XResultsForCall",
+	)
+
+	fs.BoolVar(
+		&flags.InjectErrors,
+		"inject-errors",
+		false,
+		"whether or not to generate XFailsWith, XFailsOnCall, FailAll and FailRandomly to make the methods that return an error fail",
+	)
+
+	fs.BoolVar(
+		&flags.CallRecorder,
+		"call-recorder",
+		false,
+		"whether or not to generate SetCallRecorder, which attaches the fake to a call recorder such as a callorder.Sequencer",
+	)
+
+	fs.BoolVar(
+		&flags.ReturnsWhen,
+		"returns-when",
+		false,
+		"whether or not to generate XReturnsWhen, XReturnsWhenMatches and XReturnsWhenStrict, which stub the results of a method for specific arguments",
+	)
+
+	fs.BoolVar(
+		&flags.Sequences,
+		"returns-sequence",
+		false,
+		"whether or not to generate XReturnsSequence, which stubs the results of the calls that follow with a sequence",
+	)
+
+	fs.BoolVar(
+		&flags.Reset,
+		"reset",
+		false,
+		"whether or not to generate Reset, ResetCalls and XReset, which clear what the fake recorded and was stubbed with",
+	)
+
+	fs.BoolVar(
+		&flags.Delegate,
+		"delegate",
+		false,
+		"whether or not to generate NewXWithDelegate, which builds a fake that calls a real implementation of the interface for the methods that nothing is stubbed for",
+	)
+
+	fs.BoolVar(
+		&flags.ContextErrs,
+		"context-errors",
+		false,
+		"whether or not to generate ReturnContextErrors, which makes the methods that take a context.Context return ctx.Err() for a context that is done",
+	)
+
+	fs.BoolVar(
+		&flags.All,
+		"all",
+		false,
+		"whether or not to fake every exported interface of the package at <source-path>",
+	)
+
+	fs.StringVar(
+		&flags.Match,
+		"match",
+		"",
+		"With -all, a regular expression that the names of the interfaces to fake must match",
+	)
+
+	fs.StringVar(
+		&flags.Template,
+		"template",
+		"",
+		"A template file to generate the fake with, or a directory of interface.tmpl, function.tmpl and package.tmpl files",
+	)
+
+	fs.StringVar(
+		&flags.Style,
+		"style",
+		"",
+		"The style of test double to generate instead of a counterfeiter fake: testify for a testify/mock double, or gomock for a gomock double",
+	)
+	return fs
+}
+
+// ParseFlags parses the flags of a single invocation from args and returns
+// them with the remaining arguments. Batch mode uses it to parse the
+// arguments of each go:generate directive in turn.
+func ParseFlags(args []string) (Flags, []string, error) {
+	var flags Flags
+	fs := NewFlagSet(&flags, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	err := fs.Parse(args)
+	return flags, fs.Args(), err
+}
+
+// listValue is a flag.Value for a comma separated list.
+type listValue []string
+
+func (l *listValue) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *listValue) Set(value string) error {
+	*l = splitList(value)
+	return nil
+}
@@ -0,0 +1,212 @@
+package arguments
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Manifest declares every fake of a project in one place, as an alternative to
+// go:generate directives. It is read from a counterfeiter.yaml file:
+//
+//	defaults:
+//	  param-names: true
+//	fakes:
+//	- package: ./mypackage
+//	  interface: MySpecialInterface
+//	- package: io
+//	  interface: Writer
+//	  fake-name: FakeWriter
+//	  output: ./iofakes/fake_writer.go
+//	- package: os
+//	  package-mode: true
+//	  shim-types: [File, Process]
+//	  vars: ["*"]
+//	  exclude-vars: [Args]
+//
+// A template set under defaults, such as template: ./templates, generates
+// every fake with the templates in that directory.
+type Manifest struct {
+	Path     string          `yaml:"-"`
+	Defaults ManifestOptions `yaml:"defaults"`
+	Fakes    []ManifestFake  `yaml:"fakes"`
+}
+
+// ManifestOptions are the flags that may be set for every fake of a manifest
+// at once, and overridden for a single fake.
+type ManifestOptions struct {
+	ParamNames   *bool `yaml:"param-names"`
+	DeepCopyArgs *bool `yaml:"deep-copy-args"`
+	Strict       *bool `yaml:"strict"`
+	Expectations *bool `yaml:"expectations"`
+	SyncHooks    *bool `yaml:"sync-hooks"`
+	RecordCalls  *bool `yaml:"record-calls"`
+	InjectErrors *bool `yaml:"inject-errors"`
+	CallRecorder *bool `yaml:"call-recorder"`
+	ReturnsWhen  *bool `yaml:"returns-when"`
+	Sequences    *bool `yaml:"returns-sequence"`
+	Reset        *bool `yaml:"reset"`
+	Delegate     *bool `yaml:"delegate"`
+	ContextErrs  *bool `yaml:"context-errors"`
+	// Template is the template file, or the directory of templates, to
+	// generate the fake with instead of the built-in ones.
+	Template string `yaml:"template"`
+	// Style is the style of test double to generate instead of a
+	// counterfeiter fake, testify or gomock.
+	Style string `yaml:"style"`
+}
+
+// ManifestFake is a fake declared in a manifest.
+type ManifestFake struct {
+	// Package is either the path to the directory of the target, when it
+	// starts with "." or is absolute, or its import path.
+	Package     string `yaml:"package"`
+	Interface   string `yaml:"interface"`
+	FakeName    string `yaml:"fake-name"`
+	Output      string `yaml:"output"`
+	PackageMode bool   `yaml:"package-mode"`
+	// ShimTypes are the struct types to also generate an interface and shim
+	// for in package mode.
+	ShimTypes []string `yaml:"shim-types"`
+	// Vars are the variables and constants to also generate accessors for
+	// in package mode, or "*" for all of them, except for ExcludeVars.
+	Vars        []string `yaml:"vars"`
+	ExcludeVars []string `yaml:"exclude-vars"`
+
+	ManifestOptions `yaml:",inline"`
+}
+
+// LoadManifest reads the manifest at path. Unknown keys are an error.
+func LoadManifest(path string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := yaml.UnmarshalStrict(b, m); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	m.Path = path
+	return m, nil
+}
+
+// Dir is the directory the paths in the manifest are relative to.
+func (m *Manifest) Dir() string {
+	return filepath.Dir(m.Path)
+}
+
+// Arguments returns the counterfeiter arguments for the fake, as they would
+// be written in a go:generate directive.
+func (f ManifestFake) Arguments(defaults ManifestOptions) []string {
+	var args []string
+	if f.FakeName != "" {
+		args = append(args, "-fake-name", f.FakeName)
+	}
+	if f.Output != "" {
+		args = append(args, "-o", f.Output)
+	}
+	args = appendBoolFlag(args, "-param-names", f.ParamNames, defaults.ParamNames)
+	args = appendBoolFlag(args, "-deep-copy-args", f.DeepCopyArgs, defaults.DeepCopyArgs)
+	args = appendBoolFlag(args, "-strict", f.Strict, defaults.Strict)
+	args = appendBoolFlag(args, "-expectations", f.Expectations, defaults.Expectations)
+	args = appendBoolFlag(args, "-sync-hooks", f.SyncHooks, defaults.SyncHooks)
+	args = appendBoolFlag(args, "-record-calls", f.RecordCalls, defaults.RecordCalls)
+	args = appendBoolFlag(args, "-inject-errors", f.InjectErrors, defaults.InjectErrors)
+	args = appendBoolFlag(args, "-call-recorder", f.CallRecorder, defaults.CallRecorder)
+	args = appendBoolFlag(args, "-returns-when", f.ReturnsWhen, defaults.ReturnsWhen)
+	args = appendBoolFlag(args, "-returns-sequence", f.Sequences, defaults.Sequences)
+	args = appendBoolFlag(args, "-reset", f.Reset, defaults.Reset)
+	args = appendBoolFlag(args, "-delegate", f.Delegate, defaults.Delegate)
+	args = appendBoolFlag(args, "-context-errors", f.ContextErrs, defaults.ContextErrs)
+	if f.Template != "" {
+		args = append(args, "-template", f.Template)
+	} else if defaults.Template != "" {
+		args = append(args, "-template", defaults.Template)
+	}
+	if f.Style != "" {
+		args = append(args, "-style", f.Style)
+	} else if defaults.Style != "" {
+		args = append(args, "-style", defaults.Style)
+	}
+
+	if len(f.ShimTypes) > 0 {
+		args = append(args, "-shim-types", strings.Join(f.ShimTypes, ","))
+	}
+	if len(f.Vars) > 0 {
+		args = append(args, "-vars", strings.Join(f.Vars, ","))
+	}
+	if len(f.ExcludeVars) > 0 {
+		args = append(args, "-exclude-vars", strings.Join(f.ExcludeVars, ","))
+	}
+
+	switch {
+	case f.PackageMode:
+		return append(args, "-p", f.Package)
+	case f.Package == "":
+		return append(args, f.Interface)
+	case isSourcePath(f.Package):
+		return append(args, f.Package, f.Interface)
+	default:
+		return append(args, f.Package+"."+f.Interface)
+	}
+}
+
+// appendBoolFlag appends flag to args when the option of the fake is true, or
+// when it is unset and the default is true.
+func appendBoolFlag(args []string, flag string, value *bool, defaultValue *bool) []string {
+	if value == nil {
+		value = defaultValue
+	}
+	if value != nil && *value {
+		return append(args, flag)
+	}
+	return args
+}
+
+func isSourcePath(path string) bool {
+	return strings.HasPrefix(path, ".") || filepath.IsAbs(path)
+}
+
+// Targets parses the arguments of every fake in the manifest, with paths
+// relative to the directory of the manifest, and validates them.
+func (m *Manifest) Targets(symlinkEvaler SymlinkEvaler, fileStatReader FileStatReader) ([]ParsedArguments, error) {
+	if len(m.Fakes) == 0 {
+		return nil, fmt.Errorf("%s: no fakes are declared", m.Path)
+	}
+
+	var result []ParsedArguments
+	outputs := map[string]int{}
+	for i := range m.Fakes {
+		parsed, err := m.parse(i, symlinkEvaler, fileStatReader)
+		if err != nil {
+			return nil, fmt.Errorf("%s: fakes[%d]: %v", m.Path, i, err)
+		}
+		if j, ok := outputs[parsed.OutputPath]; ok {
+			return nil, fmt.Errorf("%s: fakes[%d]: %s is also written by fakes[%d]", m.Path, i, parsed.OutputPath, j)
+		}
+		outputs[parsed.OutputPath] = i
+		result = append(result, parsed)
+	}
+	return result, nil
+}
+
+func (m *Manifest) parse(i int, symlinkEvaler SymlinkEvaler, fileStatReader FileStatReader) (ParsedArguments, error) {
+	fake := m.Fakes[i]
+	switch {
+	case fake.PackageMode && fake.Package == "":
+		return ParsedArguments{}, fmt.Errorf("package is required in package mode")
+	case fake.PackageMode && fake.Interface != "":
+		return ParsedArguments{}, fmt.Errorf("interface cannot be set in package mode")
+	case !fake.PackageMode && fake.Interface == "":
+		return ParsedArguments{}, fmt.Errorf("interface is required")
+	}
+
+	flags, args, err := ParseFlags(fake.Arguments(m.Defaults))
+	if err != nil {
+		return ParsedArguments{}, err
+	}
+	return Parse(flags, m.Dir(), args, symlinkEvaler, fileStatReader)
+}
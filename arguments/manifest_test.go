@@ -0,0 +1,176 @@
+package arguments
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestManifest(t *testing.T) {
+	spec.Run(t, "Manifest", testManifest, spec.Report(report.Terminal{}))
+}
+
+func testManifest(t *testing.T, when spec.G, it spec.S) {
+	var (
+		dir      string
+		manifest *Manifest
+		err      error
+	)
+
+	write := func(contents string) string {
+		path := filepath.Join(dir, "counterfeiter.yaml")
+		Expect(ioutil.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+		return path
+	}
+
+	it.Before(func() {
+		RegisterTestingT(t)
+		dir, err = ioutil.TempDir("", "counterfeiter-manifest")
+		Expect(err).NotTo(HaveOccurred())
+		dir, err = filepath.EvalSymlinks(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(dir, "mypackage"), 0777)).To(Succeed())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	when("the manifest is valid", func() {
+		var targets []ParsedArguments
+
+		it.Before(func() {
+			manifest, err = LoadManifest(write(`
+defaults:
+  param-names: true
+  strict: true
+  template: templates
+fakes:
+- package: ./mypackage
+  interface: MySpecialInterface
+- package: io
+  interface: Writer
+  fake-name: Writer
+  output: iofakes/writer.go
+  param-names: false
+  deep-copy-args: true
+  template: /elsewhere/writer.tmpl
+- package: os
+  package-mode: true
+  shim-types: [File, Process]
+  vars: ["*"]
+  exclude-vars: [Args]
+`))
+			Expect(err).NotTo(HaveOccurred())
+			targets, err = manifest.Targets(filepath.EvalSymlinks, os.Stat)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targets).To(HaveLen(3))
+		})
+
+		it("parses fakes in a source directory relative to the manifest", func() {
+			Expect(targets[0].SourcePackageDir).To(Equal(filepath.Join(dir, "mypackage")))
+			Expect(targets[0].InterfaceName).To(Equal("MySpecialInterface"))
+			Expect(targets[0].FakeImplName).To(Equal("FakeMySpecialInterface"))
+			Expect(targets[0].OutputPath).To(Equal(filepath.Join(dir, "mypackage", "mypackagefakes", "fake_my_special_interface.go")))
+			Expect(targets[0].DestinationPackageName).To(Equal("mypackagefakes"))
+		})
+
+		it("parses fakes given by import path", func() {
+			Expect(targets[1].PackagePath).To(Equal("io"))
+			Expect(targets[1].InterfaceName).To(Equal("Writer"))
+			Expect(targets[1].FakeImplName).To(Equal("Writer"))
+			Expect(targets[1].OutputPath).To(Equal(filepath.Join(dir, "iofakes", "writer.go")))
+			Expect(targets[1].DestinationPackageName).To(Equal("iofakes"))
+		})
+
+		it("parses fakes in package mode", func() {
+			Expect(targets[2].GenerateInterfaceAndShimFromPackageDirectory).To(BeTrue())
+			Expect(targets[2].PackagePath).To(Equal("os"))
+			Expect(targets[2].ShimTypes).To(Equal([]string{"File", "Process"}))
+			Expect(targets[2].Vars).To(Equal([]string{"*"}))
+			Expect(targets[2].ExcludeVars).To(Equal([]string{"Args"}))
+		})
+
+		it("applies the defaults unless a fake overrides them", func() {
+			Expect(targets[0].UseParamNames).To(BeTrue())
+			Expect(targets[1].UseParamNames).To(BeFalse())
+			Expect(targets[2].UseParamNames).To(BeTrue())
+			Expect(targets[0].DeepCopyArgs).To(BeFalse())
+			Expect(targets[1].DeepCopyArgs).To(BeTrue())
+			Expect(targets[0].Strict).To(BeTrue())
+			Expect(targets[1].Strict).To(BeTrue())
+		})
+
+		it("resolves the templates relative to the manifest", func() {
+			Expect(targets[0].TemplatePath).To(Equal(filepath.Join(dir, "templates")))
+			Expect(targets[1].TemplatePath).To(Equal("/elsewhere/writer.tmpl"))
+		})
+	})
+
+	it("turns a fake into the arguments of a go:generate directive", func() {
+		yes := true
+		fake := ManifestFake{Package: "./mypackage", Interface: "Thing", Output: "out.go"}
+		Expect(fake.Arguments(ManifestOptions{ParamNames: &yes})).To(Equal([]string{"-o", "out.go", "-param-names", "./mypackage", "Thing"}))
+
+		fake = ManifestFake{Package: "github.com/me/pkg", Interface: "Repository[User]"}
+		Expect(fake.Arguments(ManifestOptions{})).To(Equal([]string{"github.com/me/pkg.Repository[User]"}))
+
+		fake = ManifestFake{Package: "io", Interface: "Writer", ManifestOptions: ManifestOptions{DeepCopyArgs: &yes}}
+		Expect(fake.Arguments(ManifestOptions{Strict: &yes, Expectations: &yes, SyncHooks: &yes, RecordCalls: &yes, InjectErrors: &yes, CallRecorder: &yes, ReturnsWhen: &yes, Sequences: &yes, Reset: &yes, Delegate: &yes, ContextErrs: &yes})).To(Equal([]string{"-deep-copy-args", "-strict", "-expectations", "-sync-hooks", "-record-calls", "-inject-errors", "-call-recorder", "-returns-when", "-returns-sequence", "-reset", "-delegate", "-context-errors", "io.Writer"}))
+
+		fake = ManifestFake{Package: "io", Interface: "Reader", ManifestOptions: ManifestOptions{Style: "gomock"}}
+		Expect(fake.Arguments(ManifestOptions{Style: "testify"})).To(Equal([]string{"-style", "gomock", "io.Reader"}))
+	})
+
+	it("rejects unknown keys", func() {
+		_, err = LoadManifest(write(`
+fakes:
+- package: ./mypackage
+  interfaces: MySpecialInterface
+`))
+		Expect(err).To(MatchError(ContainSubstring("field interfaces not found")))
+	})
+
+	when("a fake is invalid", func() {
+		targets := func(contents string) error {
+			manifest, err = LoadManifest(write(contents))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = manifest.Targets(filepath.EvalSymlinks, os.Stat)
+			return err
+		}
+
+		it("requires the interface", func() {
+			Expect(targets("fakes:\n- package: ./mypackage\n")).To(MatchError(ContainSubstring("fakes[0]: interface is required")))
+		})
+
+		it("requires a source directory that exists", func() {
+			Expect(targets("fakes:\n- package: ./missing\n  interface: Thing\n")).To(MatchError(ContainSubstring("fakes[0]: No such file/directory/package")))
+		})
+
+		it("requires a valid fake name", func() {
+			Expect(targets("fakes:\n- package: io\n  interface: Writer\n  fake-name: not-valid\n")).To(MatchError(ContainSubstring(`"not-valid" is not a valid name for a fake`)))
+		})
+
+		it("rejects two fakes written to the same file", func() {
+			err := targets("fakes:\n- package: io\n  interface: Writer\n  output: fake.go\n- package: io\n  interface: Reader\n  output: fake.go\n")
+			Expect(err).To(MatchError(ContainSubstring("fakes[1]: " + filepath.Join(dir, "fake.go") + " is also written by fakes[0]")))
+		})
+
+		it("only accepts shim types in package mode", func() {
+			Expect(targets("fakes:\n- package: io\n  interface: Writer\n  shim-types: [File]\n")).To(MatchError(ContainSubstring("fakes[0]: shim types can only be generated in package mode")))
+		})
+
+		it("only accepts variables to access in package mode", func() {
+			Expect(targets("fakes:\n- package: io\n  interface: Writer\n  vars: [EOF]\n")).To(MatchError(ContainSubstring("fakes[0]: accessors for variables can only be generated in package mode")))
+		})
+
+		it("requires at least one fake", func() {
+			Expect(targets("defaults: {}\n")).To(MatchError(ContainSubstring("no fakes are declared")))
+		})
+	})
+}
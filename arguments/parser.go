@@ -1,6 +1,7 @@
 package arguments
 
 import (
+	"fmt"
 	"go/build"
 	"log"
 	"path"
@@ -8,11 +9,13 @@ import (
 	"regexp"
 	"strings"
 	"unicode"
+
+	"github.com/maxbrunsfeld/counterfeiter/generator"
 )
 
 //go:generate counterfeiter . ArgumentParser
 type ArgumentParser interface {
-	ParseArguments(...string) ParsedArguments
+	ParseArguments(Flags, ...string) ParsedArguments
 }
 
 func NewArgumentParser(
@@ -29,42 +32,104 @@ func NewArgumentParser(
 	}
 }
 
-func (argParser *argumentParser) ParseArguments(args ...string) ParsedArguments {
-	if *packageFlag {
-		return argParser.parsePackageArgs(args...)
+// parseError is a failure to parse the arguments. ParseArguments passes its
+// format and args to the FailHandler.
+type parseError struct {
+	format string
+	args   []interface{}
+}
+
+func (e parseError) Error() string {
+	return fmt.Sprintf(e.format, e.args...)
+}
+
+// Parse parses the arguments of a single invocation relative to workingDir,
+// like an ArgumentParser, and validates them. Failures are returned instead
+// of being passed to a FailHandler.
+func Parse(flags Flags, workingDir string, args []string, symlinkEvaler SymlinkEvaler, fileStatReader FileStatReader) (ParsedArguments, error) {
+	if len(args) < 1 {
+		return ParsedArguments{}, fmt.Errorf("missing arguments to counterfeiter")
+	}
+
+	parser := &argumentParser{
+		currentWorkingDir: func() string { return workingDir },
+		symlinkEvaler:     symlinkEvaler,
+		fileStatReader:    fileStatReader,
+	}
+	parsed, err := parser.parse(flags, args...)
+	if err != nil {
+		return parsed, err
+	}
+	return parsed, parsed.Validate()
+}
+
+func (argParser *argumentParser) ParseArguments(flags Flags, args ...string) ParsedArguments {
+	result, err := argParser.parse(flags, args...)
+	if err != nil {
+		failure, ok := err.(parseError)
+		if !ok {
+			failure = parseError{format: "%s", args: []interface{}{err}}
+		}
+		argParser.failHandler(failure.format, failure.args...)
+	}
+	return result
+}
+
+func (argParser *argumentParser) parse(flags Flags, args ...string) (ParsedArguments, error) {
+	var result ParsedArguments
+	var err error
+	if flags.PackageMode {
+		result = argParser.parsePackageArgs(flags, args...)
+	} else if flags.FromStruct != "" {
+		result = argParser.parseStructArgs(flags, args...)
 	} else {
-		return argParser.parseInterfaceArgs(args...)
+		result, err = argParser.parseInterfaceArgs(flags, args...)
+	}
+	if err != nil {
+		return ParsedArguments{}, err
+	}
+	result.Style = flags.Style
+	if flags.Template != "" {
+		result.TemplatePath = flags.Template
+		if !filepath.IsAbs(result.TemplatePath) {
+			result.TemplatePath = filepath.Join(argParser.currentWorkingDir(), result.TemplatePath)
+		}
 	}
+	return result, nil
 }
 
-func (argParser *argumentParser) parseInterfaceArgs(args ...string) ParsedArguments {
+func (argParser *argumentParser) parseInterfaceArgs(flags Flags, args ...string) (ParsedArguments, error) {
+	if flags.All || flags.Match != "" || len(args)-count(args, "-") > 2 {
+		return argParser.parseSeveralInterfacesArgs(flags, args...)
+	}
+
 	var interfaceName string
-	var outputPathFlagValue string
 	var rootDestinationDir string
 	var sourcePackageDir string
 	var packagePath string
 
-	if outputPathFlag != nil {
-		outputPathFlagValue = *outputPathFlag
-	}
-
 	if len(args) > 1 {
 		interfaceName = args[1]
-		sourcePackageDir = argParser.getSourceDir(args[0])
+		var err error
+		sourcePackageDir, err = argParser.getSourceDir(args[0])
+		if err != nil {
+			return ParsedArguments{}, err
+		}
 		rootDestinationDir = sourcePackageDir
 	} else {
-		fullyQualifiedInterface := strings.Split(args[0], ".")
-		interfaceName = fullyQualifiedInterface[len(fullyQualifiedInterface)-1]
+		qualifiedName, typeArgs := generator.SplitTypeArgs(args[0])
+		fullyQualifiedInterface := strings.Split(qualifiedName, ".")
+		interfaceName = fullyQualifiedInterface[len(fullyQualifiedInterface)-1] + typeArgs
 		rootDestinationDir = argParser.currentWorkingDir()
 		packagePath = strings.Join(fullyQualifiedInterface[:len(fullyQualifiedInterface)-1], ".")
 	}
 
-	fakeImplName := getFakeName(interfaceName, *fakeNameFlag)
+	fakeImplName := getFakeName(interfaceName, flags.FakeName)
 
 	outputPath := argParser.getOutputPath(
 		rootDestinationDir,
 		fakeImplName,
-		outputPathFlagValue,
+		flags.OutputPath,
 	)
 
 	packageName := restrictToValidPackageName(filepath.Base(filepath.Dir(outputPath)))
@@ -85,22 +150,113 @@ func (argParser *argumentParser) parseInterfaceArgs(args ...string) ParsedArgume
 		InterfaceName:          interfaceName,
 		DestinationPackageName: packageName,
 		FakeImplName:           fakeImplName,
-
+		ShimTypes:              flags.ShimTypes,
+		Vars:                   flags.Vars,
+		ExcludeVars:            flags.ExcludeVars,
+		UseParamNames:          flags.ParamNames,
+		DeepCopyArgs:           flags.DeepCopyArgs,
+		Strict:                 flags.Strict,
+		Expectations:           flags.Expectations,
+		SyncHooks:              flags.SyncHooks,
+		RecordCalls:            flags.RecordCalls,
+		InjectErrors:           flags.InjectErrors,
+		CallRecorder:           flags.CallRecorder,
+		ReturnsWhen:            flags.ReturnsWhen,
+		Sequences:              flags.Sequences,
+		Reset:                  flags.Reset,
+		Delegate:               flags.Delegate,
+		ContextErrs:            flags.ContextErrs,
+		Adapter:                flags.Adapter,
+
+		Check:         flags.Check,
 		PrintToStdOut: any(args, "-"),
+	}, nil
+}
+
+// parseSeveralInterfacesArgs parses the arguments of an invocation that fakes
+// several interfaces of the package at <source-path>: the ones named after
+// it, or with -all every exported one. The output path is the directory each
+// fake is written to, or the file they are all written to when it ends in
+// .go.
+func (argParser *argumentParser) parseSeveralInterfacesArgs(flags Flags, args ...string) (ParsedArguments, error) {
+	sourcePackageDir, err := argParser.getSourceDir(args[0])
+	if err != nil {
+		return ParsedArguments{}, err
+	}
+	var interfaceNames []string
+	for _, arg := range args[1:] {
+		if arg != "-" {
+			interfaceNames = append(interfaceNames, arg)
+		}
+	}
+
+	outputPath := flags.OutputPath
+	if outputPath == "" {
+		outputPath = filepath.Join(sourcePackageDir, packageNameForPath(sourcePackageDir))
+	} else if !filepath.IsAbs(outputPath) {
+		outputPath = filepath.Join(argParser.currentWorkingDir(), outputPath)
+	}
+	outputDir := outputPath
+	if filepath.Ext(outputPath) == ".go" {
+		outputDir = filepath.Dir(outputPath)
 	}
+
+	packagePath := sourcePackageDir
+	if strings.HasPrefix(packagePath, build.Default.GOPATH) {
+		packagePath = strings.Replace(packagePath, build.Default.GOPATH+"/src/", "", -1)
+	}
+
+	log.Printf("Parsed Arguments:\nInterface Names: %s\nAll Interfaces: %v\nPackage Path: %s", strings.Join(interfaceNames, ", "), flags.All, packagePath)
+	return ParsedArguments{
+		GenerateInterfaceAndShimFromPackageDirectory: false,
+		SourcePackageDir: sourcePackageDir,
+		OutputPath:       outputPath,
+		PackagePath:      packagePath,
+
+		InterfaceNames:         interfaceNames,
+		AllInterfaces:          flags.All,
+		InterfacePattern:       flags.Match,
+		DestinationPackageName: restrictToValidPackageName(filepath.Base(outputDir)),
+		FakeImplName:           flags.FakeName,
+		ShimTypes:              flags.ShimTypes,
+		Vars:                   flags.Vars,
+		ExcludeVars:            flags.ExcludeVars,
+		UseParamNames:          flags.ParamNames,
+		DeepCopyArgs:           flags.DeepCopyArgs,
+		Strict:                 flags.Strict,
+		Expectations:           flags.Expectations,
+		SyncHooks:              flags.SyncHooks,
+		RecordCalls:            flags.RecordCalls,
+		InjectErrors:           flags.InjectErrors,
+		CallRecorder:           flags.CallRecorder,
+		ReturnsWhen:            flags.ReturnsWhen,
+		Sequences:              flags.Sequences,
+		Reset:                  flags.Reset,
+		Delegate:               flags.Delegate,
+		ContextErrs:            flags.ContextErrs,
+		Adapter:                flags.Adapter,
+
+		Check:         flags.Check,
+		PrintToStdOut: any(args, "-"),
+	}, nil
 }
 
-func (argParser *argumentParser) parsePackageArgs(args ...string) ParsedArguments {
+func (argParser *argumentParser) parsePackageArgs(flags Flags, args ...string) ParsedArguments {
 	packagePath := args[0]
 	packageName := path.Base(packagePath) + "shim"
 
 	var outputPath string
-	if *outputPathFlag != "" {
+	if flags.OutputPath != "" {
 		// TODO: sensible checking of dirs and symlinks
-		outputPath = *outputPathFlag
+		outputPath = flags.OutputPath
 	} else {
 		outputPath = path.Join(argParser.currentWorkingDir(), packageName)
 	}
+	// The output path is the directory of the interface, unless it names
+	// the file to write it to.
+	if filepath.Ext(outputPath) != ".go" {
+		outputPath = filepath.Join(outputPath, path.Base(packagePath)+".go")
+	}
 
 	log.Printf("Parsed Arguments:\nPackage Name: %s\nDestination Package Name: %s", packagePath, packageName)
 	return ParsedArguments{
@@ -110,10 +266,76 @@ func (argParser *argumentParser) parsePackageArgs(args ...string) ParsedArgument
 		PackagePath:            packagePath,
 		DestinationPackageName: packageName,
 		FakeImplName:           strings.ToUpper(path.Base(packagePath))[:1] + path.Base(packagePath)[1:],
+		ShimTypes:              flags.ShimTypes,
+		Vars:                   flags.Vars,
+		ExcludeVars:            flags.ExcludeVars,
+		UseParamNames:          flags.ParamNames,
+		Check:                  flags.Check,
 		PrintToStdOut:          any(args, "-"),
 	}
 }
 
+// parseStructArgs parses the arguments of --from-struct pkg.Client
+// ClientInterface, which writes the interface extracted from pkg.Client to the
+// current directory and fakes it there.
+func (argParser *argumentParser) parseStructArgs(flags Flags, args ...string) ParsedArguments {
+	var interfaceName string
+	if len(args) > 0 {
+		interfaceName = args[0]
+	}
+
+	qualifiedName, typeArgs := generator.SplitTypeArgs(flags.FromStruct)
+	var structPackagePath string
+	structName := qualifiedName
+	if i := strings.LastIndex(qualifiedName, "."); i >= 0 {
+		structPackagePath = qualifiedName[:i]
+		structName = qualifiedName[i+1:]
+	}
+
+	sourcePackageDir := argParser.currentWorkingDir()
+	fakeImplName := getFakeName(interfaceName, flags.FakeName)
+	outputPath := argParser.getOutputPath(
+		sourcePackageDir,
+		fakeImplName,
+		flags.OutputPath,
+	)
+	snakeCaseName := strings.ToLower(camelRegexp.ReplaceAllString(interfaceName, "${1}_${2}"))
+
+	log.Printf("Parsed Arguments:\nStruct Name: %s\nStruct Package Path: %s\nInterface Name: %s", structName, structPackagePath, interfaceName)
+	return ParsedArguments{
+		GenerateInterfaceAndShimFromPackageDirectory: false,
+		SourcePackageDir: sourcePackageDir,
+		OutputPath:       outputPath,
+		PackagePath:      sourcePackageDir,
+
+		InterfaceName:          interfaceName,
+		DestinationPackageName: restrictToValidPackageName(filepath.Base(filepath.Dir(outputPath))),
+		FakeImplName:           fakeImplName,
+		UseParamNames:          flags.ParamNames,
+		DeepCopyArgs:           flags.DeepCopyArgs,
+		Strict:                 flags.Strict,
+		Expectations:           flags.Expectations,
+		SyncHooks:              flags.SyncHooks,
+		RecordCalls:            flags.RecordCalls,
+		InjectErrors:           flags.InjectErrors,
+		CallRecorder:           flags.CallRecorder,
+		ReturnsWhen:            flags.ReturnsWhen,
+		Sequences:              flags.Sequences,
+		Reset:                  flags.Reset,
+		Delegate:               flags.Delegate,
+		ContextErrs:            flags.ContextErrs,
+
+		StructPackagePath:    structPackagePath,
+		StructName:           structName + typeArgs,
+		InterfaceOutputPath:  filepath.Join(sourcePackageDir, snakeCaseName+".go"),
+		InterfacePackageName: packageNameForDir(sourcePackageDir),
+		Adapter:              flags.Adapter,
+
+		Check:         flags.Check,
+		PrintToStdOut: any(args, "-"),
+	}
+}
+
 type argumentParser struct {
 	failHandler       FailHandler
 	currentWorkingDir CurrentWorkingDir
@@ -132,10 +354,189 @@ type ParsedArguments struct {
 
 	InterfaceName string // the interface to counterfeit
 	FakeImplName  string // the name of the struct implementing the given interface
-
+	UseParamNames bool   // name parameters and results after the ones in the source
+	DeepCopyArgs  bool   // record deep copies of the arguments of the fake
+	Strict        bool   // fail calls to the fake that nothing is stubbed for
+	Expectations  bool   // generate the expectation layer of the fake
+	SyncHooks     bool   // generate the hooks for concurrent callers of the fake
+	RecordCalls   bool   // keep a record of the results, timing and panic of each call
+	InjectErrors  bool   // generate the helpers that make the fake fail
+	CallRecorder  bool   // let the fake be attached to a call recorder
+	ReturnsWhen   bool   // generate the helpers that stub results for specific arguments
+	Sequences     bool   // generate the helpers that stub a sequence of results
+	Reset         bool   // generate the helpers that clear the fake
+	Delegate      bool   // generate the constructor that wraps a real implementation
+	ContextErrs   bool   // generate the helper that makes the fake honour contexts
+
+	ShimTypes   []string // in package mode, the struct types to also generate an interface and shim for
+	Vars        []string // in package mode, the variables and constants to also generate accessors for, or * for all of them
+	ExcludeVars []string // in package mode, the variables and constants to leave out of Vars
+
+	TemplatePath string // the template file, or directory of templates, to generate the fake with instead of the built-in ones
+	Style        string // the style of test double to generate instead of a counterfeiter fake, testify or gomock
+
+	InterfaceNames   []string // the interfaces to counterfeit when there are several, instead of InterfaceName
+	AllInterfaces    bool     // counterfeit every exported interface of the package, instead of InterfaceName
+	InterfacePattern string   // with AllInterfaces, the regular expression the names of the interfaces must match
+
+	StructPackagePath    string // with --from-struct, the package path to the package containing the struct
+	StructName           string // with --from-struct, the struct to extract InterfaceName from
+	InterfaceOutputPath  string // with --from-struct, path to write the extracted interface to
+	InterfacePackageName string // with --from-struct, the name of the package the interface is written to
+	Adapter              bool   // with --from-struct, also generate an adapter for the interface
+
+	Check         bool // compare the fake with the one on disk instead of writing it
 	PrintToStdOut bool
 }
 
+// Validate returns an error if the arguments do not describe a fake that can
+// be generated.
+func (a ParsedArguments) Validate() error {
+	if err := a.validateStyle(); err != nil {
+		return err
+	}
+	if a.SeveralInterfaces() {
+		return a.validateSeveralInterfaces()
+	}
+	switch {
+	case !a.GenerateInterfaceAndShimFromPackageDirectory && a.InterfaceName == "":
+		return fmt.Errorf("the interface to fake is missing")
+	case a.PackagePath == "":
+		return fmt.Errorf("the package of the interface to fake is missing")
+	case a.OutputPath == "":
+		return fmt.Errorf("the output path is missing")
+	case a.DestinationPackageName == "":
+		return fmt.Errorf("%s is not in a valid package directory", a.OutputPath)
+	case !isIdentifier(a.FakeImplName):
+		return fmt.Errorf("%q is not a valid name for a fake", a.FakeImplName)
+	case !a.GenerateInterfaceAndShimFromPackageDirectory && len(a.ShimTypes) > 0:
+		return fmt.Errorf("shim types can only be generated in package mode")
+	case !a.GenerateInterfaceAndShimFromPackageDirectory && (len(a.Vars) > 0 || len(a.ExcludeVars) > 0):
+		return fmt.Errorf("accessors for variables can only be generated in package mode")
+	case len(a.Vars) == 0 && len(a.ExcludeVars) > 0:
+		return fmt.Errorf("--exclude-vars only leaves variables out of --vars, which is missing")
+	case a.StructName != "" && a.StructPackagePath == "":
+		return fmt.Errorf("the package of the struct to extract an interface from is missing")
+	case a.StructName != "" && !isIdentifier(a.InterfaceName):
+		return fmt.Errorf("%q is not a valid name for an interface", a.InterfaceName)
+	case a.StructName == "" && a.Adapter:
+		return fmt.Errorf("an adapter can only be generated for an interface extracted with --from-struct")
+	}
+	for _, name := range a.ShimTypes {
+		if !isIdentifier(name) {
+			return fmt.Errorf("%q is not a valid name for a shim type", name)
+		}
+	}
+	for _, name := range append(a.Vars, a.ExcludeVars...) {
+		if name != "*" && !isIdentifier(name) {
+			return fmt.Errorf("%q is not a valid name for a variable", name)
+		}
+	}
+	return nil
+}
+
+// validateStyle returns an error if the style is unknown, or given with flags
+// that only apply to counterfeiter fakes.
+func (a ParsedArguments) validateStyle() error {
+	switch {
+	case a.Style == "":
+		return nil
+	case a.Style != "testify" && a.Style != "gomock":
+		return fmt.Errorf("unknown style %q, which must be testify or gomock", a.Style)
+	case a.GenerateInterfaceAndShimFromPackageDirectory:
+		return fmt.Errorf("a %s double can only be generated for an interface, not in package mode", a.Style)
+	case a.StructName != "":
+		return fmt.Errorf("a %s double can only be generated for an interface, not with --from-struct", a.Style)
+	}
+	if flag := a.fakeOnlyFlag(); flag != "" {
+		return fmt.Errorf("%s only applies to counterfeiter fakes, not to %s doubles", flag, a.Style)
+	}
+	return nil
+}
+
+// fakeOnlyFlag returns the first of the flags given that only apply to
+// counterfeiter fakes, or "" if none of them is given.
+func (a ParsedArguments) fakeOnlyFlag() string {
+	flags := []struct {
+		name  string
+		given bool
+	}{
+		{"--deep-copy-args", a.DeepCopyArgs},
+		{"--strict", a.Strict},
+		{"--expectations", a.Expectations},
+		{"--sync-hooks", a.SyncHooks},
+		{"--record-calls", a.RecordCalls},
+		{"--inject-errors", a.InjectErrors},
+		{"--call-recorder", a.CallRecorder},
+		{"--returns-when", a.ReturnsWhen},
+		{"--returns-sequence", a.Sequences},
+		{"--reset", a.Reset},
+		{"--delegate", a.Delegate},
+		{"--context-errors", a.ContextErrs},
+	}
+	for _, flag := range flags {
+		if flag.given {
+			return flag.name
+		}
+	}
+	return ""
+}
+
+func (a ParsedArguments) validateSeveralInterfaces() error {
+	switch {
+	case a.AllInterfaces && len(a.InterfaceNames) > 0:
+		return fmt.Errorf("-all fakes every exported interface, so no interface can be named")
+	case !a.AllInterfaces && a.InterfacePattern != "":
+		return fmt.Errorf("-match can only be used with -all")
+	case a.FakeImplName != "":
+		return fmt.Errorf("a fake name can only be given for a single interface")
+	case a.DestinationPackageName == "":
+		return fmt.Errorf("%s is not in a valid package directory", a.OutputPath)
+	case len(a.ShimTypes) > 0:
+		return fmt.Errorf("shim types can only be generated in package mode")
+	case a.Adapter:
+		return fmt.Errorf("an adapter can only be generated for an interface extracted with --from-struct")
+	}
+	if _, err := regexp.Compile(a.InterfacePattern); err != nil {
+		return fmt.Errorf("-match %q is not a valid regular expression: %v", a.InterfacePattern, err)
+	}
+	return nil
+}
+
+// SeveralInterfaces is true if the arguments describe the fakes of several
+// interfaces, which ForInterface describes one at a time.
+func (a ParsedArguments) SeveralInterfaces() bool {
+	return len(a.InterfaceNames) > 0 || a.AllInterfaces || a.InterfacePattern != ""
+}
+
+// SingleFile is true if the fakes of several interfaces are all written to
+// OutputPath, rather than to a file each in the directory at OutputPath.
+func (a ParsedArguments) SingleFile() bool {
+	return filepath.Ext(a.OutputPath) == ".go"
+}
+
+// ForInterface returns the arguments for the fake of one of several
+// interfaces, named and written to a file as if it was the only one.
+func (a ParsedArguments) ForInterface(interfaceName string) ParsedArguments {
+	result := a
+	result.InterfaceNames = nil
+	result.AllInterfaces = false
+	result.InterfacePattern = ""
+	result.InterfaceName = interfaceName
+	result.FakeImplName = getFakeName(interfaceName, "")
+	if !a.SingleFile() {
+		snakeCaseName := strings.ToLower(camelRegexp.ReplaceAllString(result.FakeImplName, "${1}_${2}"))
+		result.OutputPath = filepath.Join(a.OutputPath, snakeCaseName+".go")
+	}
+	return result
+}
+
+var identifierOnlyRegexp = regexp.MustCompile(`^[\pL_][\pL\pN_]*$`)
+
+func isIdentifier(name string) bool {
+	return identifierOnlyRegexp.MatchString(name)
+}
+
 func fixupUnexportedNames(interfaceName string) string {
 	asRunes := []rune(interfaceName)
 	if len(asRunes) == 0 || !unicode.IsLower(asRunes[0]) {
@@ -147,13 +548,30 @@ func fixupUnexportedNames(interfaceName string) string {
 
 func getFakeName(interfaceName, arg string) string {
 	if arg == "" {
-		interfaceName = fixupUnexportedNames(interfaceName)
+		name, typeArgs := generator.SplitTypeArgs(interfaceName)
+		interfaceName = fixupUnexportedNames(name) + typeArgsName(typeArgs)
 		return "Fake" + interfaceName
 	} else {
 		return arg
 	}
 }
 
+var identifierRegexp = regexp.MustCompile(`[\pL_][\pL\pN_]*\.?`)
+
+// typeArgsName turns type arguments into a suffix for the name of a fake, so
+// that Repository[*models.User] is faked by FakeRepositoryUser. Package
+// qualifiers are dropped.
+func typeArgsName(typeArgs string) string {
+	var result string
+	for _, ident := range identifierRegexp.FindAllString(typeArgs, -1) {
+		if strings.HasSuffix(ident, ".") {
+			continue
+		}
+		result = result + fixupUnexportedNames(ident)
+	}
+	return result
+}
+
 var camelRegexp = regexp.MustCompile("([a-z])([A-Z])")
 
 func (argParser *argumentParser) getOutputPath(rootDestinationDir, fakeName, outputPathFlagValue string) string {
@@ -173,26 +591,47 @@ func packageNameForPath(pathToPackage string) string {
 	return packageName + "fakes"
 }
 
-func (argParser *argumentParser) getSourceDir(path string) string {
+func (argParser *argumentParser) getSourceDir(path string) (string, error) {
 	if !filepath.IsAbs(path) {
 		path = filepath.Join(argParser.currentWorkingDir(), path)
 	}
 
 	evaluatedPath, err := argParser.symlinkEvaler(path)
 	if err != nil {
-		argParser.failHandler("No such file/directory/package: '%s'", path)
+		return "", parseError{format: "No such file/directory/package: '%s'", args: []interface{}{path}}
 	}
 
 	stat, err := argParser.fileStatReader(evaluatedPath)
 	if err != nil {
-		argParser.failHandler("No such file/directory/package: '%s'", path)
+		return "", parseError{format: "No such file/directory/package: '%s'", args: []interface{}{path}}
 	}
 
 	if !stat.IsDir() {
-		return filepath.Dir(path)
+		return filepath.Dir(path), nil
 	} else {
-		return path
+		return path, nil
+	}
+}
+
+// splitList splits a comma separated flag value, dropping empty items.
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
 	}
+	return result
+}
+
+func count(slice []string, needle string) int {
+	result := 0
+	for _, str := range slice {
+		if str == needle {
+			result++
+		}
+	}
+	return result
 }
 
 func any(slice []string, needle string) bool {
@@ -205,6 +644,16 @@ func any(slice []string, needle string) bool {
 	return false
 }
 
+// packageNameForDir is the name of the package in dir, or one derived from
+// the name of dir when it has no Go files yet.
+func packageNameForDir(dir string) string {
+	pkg, err := build.ImportDir(dir, 0)
+	if err == nil && pkg.Name != "" {
+		return pkg.Name
+	}
+	return restrictToValidPackageName(filepath.Base(dir))
+}
+
 func restrictToValidPackageName(input string) string {
 	return strings.Map(func(r rune) rune {
 		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
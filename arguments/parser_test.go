@@ -24,6 +24,7 @@ func testParsingArguments(t *testing.T, when spec.G, it spec.S) {
 	var subject ArgumentParser
 	var parsedArgs ParsedArguments
 	var args []string
+	var flags Flags
 
 	var fail FailHandler
 	var cwd CurrentWorkingDir
@@ -41,14 +42,13 @@ func testParsingArguments(t *testing.T, when spec.G, it spec.S) {
 			symlinkEvaler,
 			fileStatReader,
 		)
-		parsedArgs = subject.ParseArguments(args...)
+		parsedArgs = subject.ParseArguments(flags, args...)
 	}
 
 	it.Before(func() {
 		RegisterTestingT(t)
-		*packageFlag = false
+		flags = Flags{}
 		failWasCalled = false
-		*outputPathFlag = ""
 		fail = func(msg string, args ...interface{}) {
 			failWasCalled = true
 			failWasCalledWithMessage = msg
@@ -70,7 +70,7 @@ func testParsingArguments(t *testing.T, when spec.G, it spec.S) {
 	when("when the -p flag is provided", func() {
 		it.Before(func() {
 			args = []string{"os"}
-			*packageFlag = true
+			flags.PackageMode = true
 			justBefore()
 		})
 
@@ -82,11 +82,65 @@ func testParsingArguments(t *testing.T, when spec.G, it spec.S) {
 		when("given a stdlib package", func() {
 			it("sets arguments as expected", func() {
 				Expect(parsedArgs.SourcePackageDir).To(Equal("os"))
-				Expect(parsedArgs.OutputPath).To(Equal(path.Join(cwd(), "osshim")))
+				Expect(parsedArgs.OutputPath).To(Equal(path.Join(cwd(), "osshim", "os.go")))
 				Expect(parsedArgs.DestinationPackageName).To(Equal("osshim"))
 			})
 		})
 
+		when("given an output directory", func() {
+			it.Before(func() {
+				flags.OutputPath = "some/dir"
+				justBefore()
+			})
+
+			it("writes the interface to a file named after the package", func() {
+				Expect(parsedArgs.OutputPath).To(Equal(filepath.Join("some/dir", "os.go")))
+			})
+		})
+
+		when("given an output file", func() {
+			it.Before(func() {
+				flags.OutputPath = "some/dir/interfaces.go"
+				justBefore()
+			})
+
+			it("writes the interface to that file", func() {
+				Expect(parsedArgs.OutputPath).To(Equal("some/dir/interfaces.go"))
+			})
+		})
+
+		when("given shim types", func() {
+			it.Before(func() {
+				flags.ShimTypes = []string{"File", "Process"}
+				justBefore()
+			})
+
+			it("passes the types on", func() {
+				Expect(parsedArgs.ShimTypes).To(Equal([]string{"File", "Process"}))
+				Expect(parsedArgs.Validate()).To(Succeed())
+			})
+		})
+
+		when("given variables to access", func() {
+			it.Before(func() {
+				flags.Vars = []string{"*"}
+				flags.ExcludeVars = []string{"Args"}
+				justBefore()
+			})
+
+			it("passes the variables on", func() {
+				Expect(parsedArgs.Vars).To(Equal([]string{"*"}))
+				Expect(parsedArgs.ExcludeVars).To(Equal([]string{"Args"}))
+				Expect(parsedArgs.Validate()).To(Succeed())
+			})
+
+			it("only excludes variables from the ones to access", func() {
+				flags.Vars = nil
+				justBefore()
+				Expect(parsedArgs.Validate()).To(MatchError("--exclude-vars only leaves variables out of --vars, which is missing"))
+			})
+		})
+
 		when("given a relative path to a path to a package", func() {})
 	})
 
@@ -123,9 +177,311 @@ func testParsingArguments(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("when the interface is an instance of a generic interface", func() {
+		it.Before(func() {
+			args = []string{"my/mypackage", "Repository[*models.User]"}
+			justBefore()
+		})
+
+		it("keeps the type arguments in the interface name", func() {
+			Expect(parsedArgs.InterfaceName).To(Equal("Repository[*models.User]"))
+		})
+
+		it("names the fake after the interface and its type arguments", func() {
+			Expect(parsedArgs.FakeImplName).To(Equal("FakeRepositoryUser"))
+		})
+
+		it("snake cases the filename for the output directory", func() {
+			Expect(parsedArgs.OutputPath).To(Equal(
+				filepath.Join(
+					parsedArgs.SourcePackageDir,
+					"mypackagefakes",
+					"fake_repository_user.go",
+				),
+			))
+		})
+
+		when("the interface is fully qualified", func() {
+			it.Before(func() {
+				args = []string{"github.com/me/mypackage.Cache[string, other.Thing]"}
+				justBefore()
+			})
+
+			it("splits the package path at the last dot before the type arguments", func() {
+				Expect(parsedArgs.PackagePath).To(Equal("github.com/me/mypackage"))
+				Expect(parsedArgs.InterfaceName).To(Equal("Cache[string, other.Thing]"))
+				Expect(parsedArgs.FakeImplName).To(Equal("FakeCacheStringThing"))
+			})
+		})
+	})
+
+	when("when the --param-names flag is provided", func() {
+		it.Before(func() {
+			flags.ParamNames = true
+			args = []string{"my/mypackage", "MySpecialInterface"}
+			justBefore()
+		})
+
+		it("asks for the parameter names from the source", func() {
+			Expect(parsedArgs.UseParamNames).To(BeTrue())
+		})
+	})
+
+	when("when the --deep-copy-args flag is provided", func() {
+		it.Before(func() {
+			flags.DeepCopyArgs = true
+			args = []string{"my/mypackage", "MySpecialInterface"}
+			justBefore()
+		})
+
+		it("asks for the arguments to be deep copied", func() {
+			Expect(parsedArgs.DeepCopyArgs).To(BeTrue())
+		})
+	})
+
+	when("when the --strict flag is provided", func() {
+		it.Before(func() {
+			flags.Strict = true
+			args = []string{"my/mypackage", "MySpecialInterface"}
+			justBefore()
+		})
+
+		it("asks for a strict fake", func() {
+			Expect(parsedArgs.Strict).To(BeTrue())
+		})
+	})
+
+	when("when the --expectations flag is provided", func() {
+		it.Before(func() {
+			flags.Expectations = true
+			args = []string{"my/mypackage", "MySpecialInterface"}
+			justBefore()
+		})
+
+		it("asks for the expectation layer", func() {
+			Expect(parsedArgs.Expectations).To(BeTrue())
+		})
+	})
+
+	when("when the --sync-hooks flag is provided", func() {
+		it.Before(func() {
+			flags.SyncHooks = true
+			args = []string{"my/mypackage", "MySpecialInterface"}
+			justBefore()
+		})
+
+		it("asks for the hooks for concurrent callers", func() {
+			Expect(parsedArgs.SyncHooks).To(BeTrue())
+		})
+	})
+
+	when("when the --record-calls flag is provided", func() {
+		it.Before(func() {
+			flags.RecordCalls = true
+			args = []string{"my/mypackage", "MySpecialInterface"}
+			justBefore()
+		})
+
+		it("asks for a record of each call", func() {
+			Expect(parsedArgs.RecordCalls).To(BeTrue())
+		})
+	})
+
+	when("when the --inject-errors flag is provided", func() {
+		it.Before(func() {
+			flags.InjectErrors = true
+			args = []string{"my/mypackage", "MySpecialInterface"}
+			justBefore()
+		})
+
+		it("asks for the helpers that make the fake fail", func() {
+			Expect(parsedArgs.InjectErrors).To(BeTrue())
+		})
+	})
+
+	when("when the --call-recorder flag is provided", func() {
+		it.Before(func() {
+			flags.CallRecorder = true
+			args = []string{"my/mypackage", "MySpecialInterface"}
+			justBefore()
+		})
+
+		it("asks for a fake that can be attached to a call recorder", func() {
+			Expect(parsedArgs.CallRecorder).To(BeTrue())
+		})
+	})
+
+	when("when the --returns-when flag is provided", func() {
+		it.Before(func() {
+			flags.ReturnsWhen = true
+			args = []string{"my/mypackage", "MySpecialInterface"}
+			justBefore()
+		})
+
+		it("asks for the helpers that stub results for specific arguments", func() {
+			Expect(parsedArgs.ReturnsWhen).To(BeTrue())
+		})
+	})
+
+	when("when the --returns-sequence flag is provided", func() {
+		it.Before(func() {
+			flags.Sequences = true
+			args = []string{"my/mypackage", "MySpecialInterface"}
+			justBefore()
+		})
+
+		it("asks for the helpers that stub a sequence of results", func() {
+			Expect(parsedArgs.Sequences).To(BeTrue())
+		})
+	})
+
+	when("when the --reset flag is provided", func() {
+		it.Before(func() {
+			flags.Reset = true
+			args = []string{"my/mypackage", "MySpecialInterface"}
+			justBefore()
+		})
+
+		it("asks for the helpers that clear the fake", func() {
+			Expect(parsedArgs.Reset).To(BeTrue())
+		})
+	})
+
+	when("when the --delegate flag is provided", func() {
+		it.Before(func() {
+			flags.Delegate = true
+			args = []string{"my/mypackage", "MySpecialInterface"}
+			justBefore()
+		})
+
+		it("generates the constructor that wraps a real implementation", func() {
+			Expect(parsedArgs.Delegate).To(BeTrue())
+		})
+	})
+
+	when("when the --context-errors flag is provided", func() {
+		it.Before(func() {
+			flags.ContextErrs = true
+			args = []string{"my/mypackage", "MySpecialInterface"}
+			justBefore()
+		})
+
+		it("generates the helper that makes the fake honour contexts", func() {
+			Expect(parsedArgs.ContextErrs).To(BeTrue())
+		})
+	})
+
+	when("when the --from-struct flag is provided", func() {
+		it.Before(func() {
+			flags.FromStruct = "github.com/someone/sdk.Client"
+			flags.Adapter = true
+			args = []string{"ClientInterface"}
+			justBefore()
+		})
+
+		it("splits the struct from its package path", func() {
+			Expect(parsedArgs.StructPackagePath).To(Equal("github.com/someone/sdk"))
+			Expect(parsedArgs.StructName).To(Equal("Client"))
+		})
+
+		it("writes the interface to the current directory", func() {
+			Expect(parsedArgs.InterfaceName).To(Equal("ClientInterface"))
+			Expect(parsedArgs.InterfaceOutputPath).To(Equal(filepath.Join(cwd(), "client_interface.go")))
+			Expect(parsedArgs.InterfacePackageName).To(Equal("workspace"))
+			Expect(parsedArgs.Adapter).To(BeTrue())
+		})
+
+		it("fakes the interface in the current directory", func() {
+			Expect(parsedArgs.PackagePath).To(Equal(cwd()))
+			Expect(parsedArgs.FakeImplName).To(Equal("FakeClientInterface"))
+			Expect(parsedArgs.OutputPath).To(Equal(filepath.Join(cwd(), "workspacefakes", "fake_client_interface.go")))
+			Expect(parsedArgs.DestinationPackageName).To(Equal("workspacefakes"))
+			Expect(parsedArgs.Validate()).To(Succeed())
+		})
+
+		it("requires the package of the struct", func() {
+			flags.FromStruct = "Client"
+			justBefore()
+			Expect(parsedArgs.Validate()).To(MatchError("the package of the struct to extract an interface from is missing"))
+		})
+	})
+
+	when("when the --adapter flag is provided without --from-struct", func() {
+		it.Before(func() {
+			flags.Adapter = true
+			args = []string{"my/mypackage", "MySpecialInterface"}
+			justBefore()
+		})
+
+		it("is invalid", func() {
+			Expect(parsedArgs.Validate()).To(MatchError("an adapter can only be generated for an interface extracted with --from-struct"))
+		})
+	})
+
+	when("when the --template flag is provided", func() {
+		it.Before(func() {
+			flags.Template = "templates/fake.tmpl"
+			args = []string{"my/mypackage", "MySpecialInterface"}
+			justBefore()
+		})
+
+		it("generates the fake with the template relative to the working directory", func() {
+			Expect(parsedArgs.TemplatePath).To(Equal("/home/test-user/workspace/templates/fake.tmpl"))
+		})
+	})
+
+	when("when the --style flag is provided", func() {
+		it.Before(func() {
+			flags.Style = "testify"
+			args = []string{"my/mypackage", "MySpecialInterface"}
+		})
+
+		it("generates a double of that style", func() {
+			justBefore()
+			Expect(parsedArgs.Style).To(Equal("testify"))
+			Expect(parsedArgs.Validate()).To(Succeed())
+		})
+
+		it("rejects an unknown style", func() {
+			flags.Style = "mockery"
+			justBefore()
+			Expect(parsedArgs.Validate()).To(MatchError(`unknown style "mockery", which must be testify or gomock`))
+		})
+
+		it("rejects package mode", func() {
+			flags.PackageMode = true
+			args = []string{"os"}
+			justBefore()
+			Expect(parsedArgs.Validate()).To(MatchError("a testify double can only be generated for an interface, not in package mode"))
+		})
+
+		it("rejects the flags that only apply to counterfeiter fakes", func() {
+			flags.Strict = true
+			justBefore()
+			Expect(parsedArgs.Validate()).To(MatchError("--strict only applies to counterfeiter fakes, not to testify doubles"))
+
+			flags.Strict = false
+			flags.CallRecorder = true
+			justBefore()
+			Expect(parsedArgs.Validate()).To(MatchError("--call-recorder only applies to counterfeiter fakes, not to testify doubles"))
+		})
+	})
+
+	when("when the --check flag is provided", func() {
+		it.Before(func() {
+			flags.Check = true
+			args = []string{"my/mypackage", "MySpecialInterface"}
+			justBefore()
+		})
+
+		it("only checks the fake on disk", func() {
+			Expect(parsedArgs.Check).To(BeTrue())
+		})
+	})
+
 	when("when a single argument is provided with the output directory", func() {
 		it.Before(func() {
-			*outputPathFlag = "/tmp/foo"
+			flags.OutputPath = "/tmp/foo"
 			args = []string{"io.Writer"}
 			justBefore()
 		})
@@ -333,6 +689,112 @@ func testParsingArguments(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("when several interfaces are provided", func() {
+		it.Before(func() {
+			args = []string{"my/mypackage", "MySpecialInterface", "myOtherInterface"}
+			justBefore()
+		})
+
+		it("fakes each of them", func() {
+			Expect(parsedArgs.SeveralInterfaces()).To(BeTrue())
+			Expect(parsedArgs.InterfaceNames).To(Equal([]string{"MySpecialInterface", "myOtherInterface"}))
+			Expect(parsedArgs.Validate()).To(Succeed())
+		})
+
+		it("writes a file each to the fakes directory", func() {
+			Expect(parsedArgs.SingleFile()).To(BeFalse())
+			Expect(parsedArgs.DestinationPackageName).To(Equal("mypackagefakes"))
+
+			other := parsedArgs.ForInterface("myOtherInterface")
+			Expect(other.SeveralInterfaces()).To(BeFalse())
+			Expect(other.InterfaceName).To(Equal("myOtherInterface"))
+			Expect(other.FakeImplName).To(Equal("FakeMyOtherInterface"))
+			Expect(other.OutputPath).To(Equal(
+				filepath.Join(
+					parsedArgs.SourcePackageDir,
+					"mypackagefakes",
+					"fake_my_other_interface.go",
+				),
+			))
+		})
+
+		when("with an output file", func() {
+			it.Before(func() {
+				flags.OutputPath = "fakes/fakes.go"
+				justBefore()
+			})
+
+			it("writes them all to it", func() {
+				Expect(parsedArgs.SingleFile()).To(BeTrue())
+				Expect(parsedArgs.OutputPath).To(Equal("/home/test-user/workspace/fakes/fakes.go"))
+				Expect(parsedArgs.DestinationPackageName).To(Equal("fakes"))
+				Expect(parsedArgs.ForInterface("MySpecialInterface").OutputPath).To(Equal(parsedArgs.OutputPath))
+			})
+		})
+
+		when("with a fake name", func() {
+			it.Before(func() {
+				flags.FakeName = "FakeSomething"
+				justBefore()
+			})
+
+			it("is invalid", func() {
+				Expect(parsedArgs.Validate()).To(MatchError("a fake name can only be given for a single interface"))
+			})
+		})
+	})
+
+	when("when the -all flag is provided", func() {
+		it.Before(func() {
+			flags.All = true
+			flags.Match = "^My"
+			args = []string{"my/mypackage"}
+			justBefore()
+		})
+
+		it("fakes the interfaces that match", func() {
+			Expect(parsedArgs.SeveralInterfaces()).To(BeTrue())
+			Expect(parsedArgs.AllInterfaces).To(BeTrue())
+			Expect(parsedArgs.InterfacePattern).To(Equal("^My"))
+			Expect(parsedArgs.InterfaceNames).To(BeEmpty())
+			Expect(parsedArgs.Validate()).To(Succeed())
+		})
+
+		when("with interfaces named as well", func() {
+			it.Before(func() {
+				args = []string{"my/mypackage", "MySpecialInterface"}
+				justBefore()
+			})
+
+			it("is invalid", func() {
+				Expect(parsedArgs.Validate()).To(MatchError("-all fakes every exported interface, so no interface can be named"))
+			})
+		})
+
+		when("with a pattern that is not a regular expression", func() {
+			it.Before(func() {
+				flags.Match = "My("
+				justBefore()
+			})
+
+			it("is invalid", func() {
+				Expect(parsedArgs.Validate()).To(MatchError(ContainSubstring(`-match "My(" is not a valid regular expression`)))
+			})
+		})
+	})
+
+	when("when the -match flag is provided without -all", func() {
+		it.Before(func() {
+			flags.Match = "^My"
+			args = []string{"my/mypackage"}
+			justBefore()
+		})
+
+		it("is invalid", func() {
+			Expect(parsedArgs.Validate()).To(MatchError("-match can only be used with -all"))
+		})
+	})
+
 	when("when the output dir contains underscores in package name", func() {
 		it.Before(func() {
 			args = []string{"fake_command_runner", "MySpecialInterface"}
@@ -343,6 +805,47 @@ func testParsingArguments(t *testing.T, when spec.G, it spec.S) {
 			Expect(parsedArgs.DestinationPackageName).To(Equal("fake_command_runnerfakes"))
 		})
 	})
+
+	when("parsing the flags", func() {
+		it("returns them with the remaining arguments", func() {
+			flags, rest, err := ParseFlags([]string{"-p", "--shim-types", "File, Process,", "-param-names", "os", "-"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(flags).To(Equal(Flags{
+				PackageMode:  true,
+				ShimTypes:    []string{"File", "Process"},
+				ParamNames:   true,
+				OutputFormat: "text",
+			}))
+			Expect(rest).To(Equal([]string{"os", "-"}))
+		})
+
+		it("rejects unknown flags", func() {
+			_, _, err := ParseFlags([]string{"--not-a-flag", "os"})
+			Expect(err).To(MatchError(ContainSubstring("flag provided but not defined: -not-a-flag")))
+		})
+	})
+
+	when("parsing the arguments with Parse()", func() {
+		it("returns the parsed arguments", func() {
+			parsed, err := Parse(Flags{}, "/home/test-user/workspace", []string{"my/mypackage", "MySpecialInterface"}, symlinkEvaler, fileStatReader)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed.SourcePackageDir).To(Equal(filepath.Join("/home/test-user/workspace", "my/mypackage")))
+			Expect(parsed.FakeImplName).To(Equal("FakeMySpecialInterface"))
+		})
+
+		it("returns failures as errors", func() {
+			symlinkEvaler = func(input string) (string, error) {
+				return "", errors.New("aww shucks")
+			}
+			_, err := Parse(Flags{}, "/home/test-user/workspace", []string{"my/mypackage", "MySpecialInterface"}, symlinkEvaler, fileStatReader)
+			Expect(err).To(MatchError("No such file/directory/package: '/home/test-user/workspace/my/mypackage'"))
+		})
+
+		it("requires arguments", func() {
+			_, err := Parse(Flags{}, "/home/test-user/workspace", nil, symlinkEvaler, fileStatReader)
+			Expect(err).To(MatchError("missing arguments to counterfeiter"))
+		})
+	})
 }
 
 func fakeFileInfo(filename string, isDir bool) os.FileInfo {
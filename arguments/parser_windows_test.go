@@ -22,6 +22,7 @@ func testParsingArguments(t *testing.T, when spec.G, it spec.S) {
 	var subject ArgumentParser
 	var parsedArgs ParsedArguments
 	var args []string
+	var flags Flags
 
 	var fail FailHandler
 	var cwd CurrentWorkingDir
@@ -39,16 +40,15 @@ func testParsingArguments(t *testing.T, when spec.G, it spec.S) {
 			symlinkEvaler,
 			fileStatReader,
 		)
-		parsedArgs = subject.ParseArguments(args...)
+		parsedArgs = subject.ParseArguments(flags, args...)
 	}
 
 	it.Before(func() {
 		RegisterTestingT(t)
-		*packageFlag = false
+		flags = Flags{}
 		failWasCalled = false
 		failWasCalledWithMessage = ""
 		failWasCalledWithArgs = []interface{}{}
-		*outputPathFlag = ""
 		fail = func(msg string, args ...interface{}) {
 			failWasCalled = true
 			failWasCalledWithMessage = msg
@@ -68,7 +68,7 @@ func testParsingArguments(t *testing.T, when spec.G, it spec.S) {
 
 	when("when a single argument is provided with the output directory", func() {
 		it.Before(func() {
-			*outputPathFlag = "C:\\tmp\\foo"
+			flags.OutputPath = "C:\\tmp\\foo"
 			args = []string{"io.Writer"}
 			justBefore()
 		})
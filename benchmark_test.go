@@ -1,12 +1,13 @@
 package main
 
 import (
+	"context"
 	"io/ioutil"
 	"log"
 	"path/filepath"
 	"testing"
 
-	"github.com/maxbrunsfeld/counterfeiter/arguments"
+	"github.com/maxbrunsfeld/counterfeiter/counterfeiter"
 )
 
 func BenchmarkSingleRun(b *testing.B) {
@@ -17,19 +18,16 @@ func BenchmarkSingleRun(b *testing.B) {
 	}
 	log.SetOutput(ioutil.Discard)
 
-	args := arguments.ParsedArguments{
-		GenerateInterfaceAndShimFromPackageDirectory: false,
-		SourcePackageDir:       workingDir,
-		PackagePath:            workingDir,
-		OutputPath:             filepath.Join(workingDir, "fixturesfakes", "fake_something.go"),
-		DestinationPackageName: "fixturesfakes",
-		InterfaceName:          "Something",
-		FakeImplName:           "FakeSomething",
-		PrintToStdOut:          false,
+	opts := counterfeiter.Options{
+		Dir:    workingDir,
+		Fakes:  []counterfeiter.Fake{{SourceDir: workingDir, Interface: "Something"}},
+		DryRun: true,
 	}
 
 	b.StartTimer()
 	for i := 0; i < b.N; i++ {
-		doGenerate(workingDir, args)
+		if _, err := counterfeiter.Generate(context.Background(), opts); err != nil {
+			b.Fatal(err)
+		}
 	}
 }
@@ -0,0 +1,177 @@
+// Package callorder records the calls made to counterfeiter fakes generated
+// with --call-recorder in a single, sequenced log, so that tests can assert
+// the order of calls across methods and across fakes.
+//
+//	seq := callorder.NewSequencer(fakeFile, fakeWriter)
+//	subject.Run()
+//	seq.AssertOrder(t,
+//		callorder.Called(fakeFile, "Open"),
+//		callorder.Called(fakeWriter, "Write"),
+//		callorder.Called(fakeFile, "Close"),
+//	)
+package callorder
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Recorder is notified of every call made to a fake it is attached to.
+type Recorder interface {
+	RecordCall(fake interface{}, method string, args []interface{})
+}
+
+// Fake is implemented by the fakes that counterfeiter generates with
+// --call-recorder. They do not import this package, so SetCallRecorder takes
+// an unnamed interface with the method set of Recorder rather than Recorder.
+type Fake interface {
+	SetCallRecorder(interface {
+		RecordCall(interface{}, string, []interface{})
+	})
+}
+
+// Call is a single call made to a fake attached to a Sequencer.
+type Call struct {
+	Seq    uint64        // the position of the call in the log, starting at 1
+	Fake   interface{}   // the fake that was called
+	Method string        // the name of the method that was called
+	Args   []interface{} // the arguments the method was called with
+}
+
+func (c Call) String() string {
+	return fmt.Sprintf("#%d %s", c.Seq, describe(c.Fake, c.Method))
+}
+
+// Sequencer is a Recorder that keeps the calls made to every fake attached to
+// it in the order they were made. It is safe for concurrent use.
+type Sequencer struct {
+	mutex sync.RWMutex
+	calls []Call
+}
+
+// NewSequencer returns a Sequencer attached to fakes.
+func NewSequencer(fakes ...Fake) *Sequencer {
+	s := &Sequencer{}
+	s.Attach(fakes...)
+	return s
+}
+
+// Attach makes the calls to fakes be recorded by s. A fake is attached to at
+// most one recorder at a time.
+func (s *Sequencer) Attach(fakes ...Fake) {
+	for i := range fakes {
+		fakes[i].SetCallRecorder(s)
+	}
+}
+
+// RecordCall appends a call to the log, giving it the next sequence number.
+func (s *Sequencer) RecordCall(fake interface{}, method string, args []interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.calls = append(s.calls, Call{
+		Seq:    uint64(len(s.calls) + 1),
+		Fake:   fake,
+		Method: method,
+		Args:   args,
+	})
+}
+
+// Calls returns every call recorded so far, in order.
+func (s *Sequencer) Calls() []Call {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	calls := make([]Call, len(s.calls))
+	copy(calls, s.calls)
+	return calls
+}
+
+// CallsTo returns the calls recorded so far for a single fake, in order.
+func (s *Sequencer) CallsTo(fake interface{}) []Call {
+	var calls []Call
+	for _, call := range s.Calls() {
+		if call.Fake == fake {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Step identifies a call expected by CheckOrder and AssertOrder.
+type Step struct {
+	Fake   interface{}
+	Method string
+}
+
+// Called returns the Step for a call to method on fake.
+func Called(fake interface{}, method string) Step {
+	return Step{Fake: fake, Method: method}
+}
+
+func (s Step) String() string {
+	return describe(s.Fake, s.Method)
+}
+
+func (s Step) matches(call Call) bool {
+	return call.Fake == s.Fake && call.Method == s.Method
+}
+
+// CheckOrder returns an error unless the recorded calls include steps in the
+// given order. Other calls may be made before, between and after them.
+func (s *Sequencer) CheckOrder(steps ...Step) error {
+	calls := s.Calls()
+	next := 0
+	for i := 0; i < len(calls) && next < len(steps); i++ {
+		if steps[next].matches(calls[i]) {
+			next++
+		}
+	}
+	if next == len(steps) {
+		return nil
+	}
+	return fmt.Errorf("expected %s to be called after %s, but the calls were:\n%s",
+		steps[next], describeSteps(steps[:next]), describeCalls(calls))
+}
+
+// TB is the subset of testing.TB used by AssertOrder.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertOrder reports an error to t unless the recorded calls include steps in
+// the given order, as checked by CheckOrder. It returns whether they did.
+func (s *Sequencer) AssertOrder(t TB, steps ...Step) bool {
+	t.Helper()
+	if err := s.CheckOrder(steps...); err != nil {
+		t.Errorf("%v", err)
+		return false
+	}
+	return true
+}
+
+func describe(fake interface{}, method string) string {
+	return fmt.Sprintf("%T.%s", fake, method)
+}
+
+func describeSteps(steps []Step) string {
+	if len(steps) == 0 {
+		return "the start"
+	}
+	names := []string{}
+	for i := range steps {
+		names = append(names, steps[i].String())
+	}
+	return strings.Join(names, ", ")
+}
+
+func describeCalls(calls []Call) string {
+	if len(calls) == 0 {
+		return "\t(none)"
+	}
+	lines := []string{}
+	for i := range calls {
+		lines = append(lines, "\t"+calls[i].String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,130 @@
+package callorder_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/maxbrunsfeld/counterfeiter/callorder"
+	"github.com/maxbrunsfeld/counterfeiter/fixtures/fixturesfakes"
+)
+
+// The fakes that counterfeiter generates for interfaces and functions with
+// --call-recorder accept a Sequencer as their call recorder.
+var (
+	_ callorder.Recorder = new(callorder.Sequencer)
+	_ callorder.Fake     = new(fixturesfakes.FakeOrdered)
+	_ callorder.Fake     = new(fixturesfakes.FakeOrderedFunc)
+)
+
+// fakeThing records its calls the same way a generated fake does.
+type fakeThing struct {
+	recorder callorder.Recorder
+}
+
+func (f *fakeThing) SetCallRecorder(recorder interface {
+	RecordCall(interface{}, string, []interface{})
+}) {
+	f.recorder = recorder
+}
+
+func (f *fakeThing) call(method string, args ...interface{}) {
+	if f.recorder != nil {
+		f.recorder.RecordCall(f, method, args)
+	}
+}
+
+type fakeT struct {
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestCallOrder(t *testing.T) {
+	spec.Run(t, "CallOrder", testCallOrder, spec.Report(report.Terminal{}))
+}
+
+func testCallOrder(t *testing.T, when spec.G, it spec.S) {
+	var (
+		a   *fakeThing
+		b   *fakeThing
+		seq *callorder.Sequencer
+	)
+
+	it.Before(func() {
+		RegisterTestingT(t)
+		a = &fakeThing{}
+		b = &fakeThing{}
+		seq = callorder.NewSequencer(a, b)
+	})
+
+	it("records the calls to every attached fake in order", func() {
+		a.call("Open", "file")
+		b.call("Write", []byte("data"))
+		a.call("Close")
+
+		calls := seq.Calls()
+		Expect(calls).To(HaveLen(3))
+		Expect(calls[0]).To(Equal(callorder.Call{Seq: 1, Fake: a, Method: "Open", Args: []interface{}{"file"}}))
+		Expect(calls[1].Seq).To(Equal(uint64(2)))
+		Expect(calls[1].Fake).To(BeIdenticalTo(b))
+		Expect(calls[2].Method).To(Equal("Close"))
+	})
+
+	it("can list the calls to a single fake", func() {
+		a.call("Open")
+		b.call("Write")
+		a.call("Close")
+
+		calls := seq.CallsTo(a)
+		Expect(calls).To(HaveLen(2))
+		Expect(calls[0].Seq).To(Equal(uint64(1)))
+		Expect(calls[1].Seq).To(Equal(uint64(3)))
+	})
+
+	when("checking the order of calls", func() {
+		it.Before(func() {
+			a.call("Open")
+			b.call("Write")
+			b.call("Flush")
+			a.call("Close")
+		})
+
+		it("accepts calls made in the given order", func() {
+			Expect(seq.CheckOrder(
+				callorder.Called(a, "Open"),
+				callorder.Called(b, "Write"),
+				callorder.Called(a, "Close"),
+			)).To(Succeed())
+		})
+
+		it("rejects calls made in another order", func() {
+			err := seq.CheckOrder(
+				callorder.Called(a, "Close"),
+				callorder.Called(b, "Write"),
+			)
+			Expect(err).To(MatchError(ContainSubstring("expected *callorder_test.fakeThing.Write to be called after *callorder_test.fakeThing.Close")))
+			Expect(err).To(MatchError(ContainSubstring("#3 *callorder_test.fakeThing.Flush")))
+		})
+
+		it("tells fakes of the same type apart", func() {
+			Expect(seq.CheckOrder(callorder.Called(b, "Open"))).NotTo(Succeed())
+		})
+
+		it("reports failed assertions to the test", func() {
+			ft := &fakeT{}
+			Expect(seq.AssertOrder(ft, callorder.Called(a, "Open"), callorder.Called(a, "Close"))).To(BeTrue())
+			Expect(ft.errors).To(BeEmpty())
+
+			Expect(seq.AssertOrder(ft, callorder.Called(a, "Close"), callorder.Called(a, "Open"))).To(BeFalse())
+			Expect(ft.errors).To(HaveLen(1))
+		})
+	})
+}
@@ -0,0 +1,191 @@
+// Package command finds the counterfeiter invocations declared in go:generate
+// directives, so that they can all be run from a single process.
+package command
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Invocation is a counterfeiter command found in a go:generate directive.
+type Invocation struct {
+	Args             []string // the arguments following the counterfeiter command
+	File             string   // the file containing the directive
+	Line             int      // the line of the directive in File
+	Package          string   // the name of the package containing File
+	WorkingDirectory string   // the directory go generate would run the command in
+}
+
+// Detect returns the counterfeiter invocations in the go:generate directives of
+// the packages matching patterns, relative to workingDir. Invocations are
+// ordered by file and line.
+func Detect(workingDir string, patterns ...string) ([]Invocation, error) {
+	return DetectContext(context.Background(), workingDir, patterns...)
+}
+
+// DetectContext is like Detect, but stops loading the packages when ctx is
+// done.
+func DetectContext(ctx context.Context, workingDir string, patterns ...string) ([]Invocation, error) {
+	p, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Mode:    packages.LoadFiles,
+		Dir:     workingDir,
+		Tests:   true,
+	}, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]string{}
+	for i := range p {
+		if len(p[i].Errors) > 0 {
+			return nil, p[i].Errors[0]
+		}
+		for _, file := range p[i].GoFiles {
+			files[file] = p[i].Name
+		}
+	}
+
+	names := []string{}
+	for file := range files {
+		names = append(names, file)
+	}
+	sort.Strings(names)
+
+	var result []Invocation
+	for _, file := range names {
+		invocations, err := scanFile(file, files[file])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, invocations...)
+	}
+	return result, nil
+}
+
+func scanFile(file string, pkg string) ([]Invocation, error) {
+	fh, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	var result []Invocation
+	scanner := bufio.NewScanner(fh)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if !isGoGenerate(text) {
+			continue
+		}
+		words, err := splitDirective(text[len("//go:generate"):], func(name string) string {
+			switch name {
+			case "GOFILE":
+				return filepath.Base(file)
+			case "GOLINE":
+				return strconv.Itoa(line)
+			case "GOPACKAGE":
+				return pkg
+			case "DOLLAR":
+				return "$"
+			}
+			return os.Getenv(name)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", file, line, err)
+		}
+		args, ok := counterfeiterArgs(words)
+		if !ok {
+			continue
+		}
+		result = append(result, Invocation{
+			Args:             args,
+			File:             file,
+			Line:             line,
+			Package:          pkg,
+			WorkingDirectory: filepath.Dir(file),
+		})
+	}
+	return result, scanner.Err()
+}
+
+func isGoGenerate(line string) bool {
+	if !strings.HasPrefix(line, "//go:generate") {
+		return false
+	}
+	rest := line[len("//go:generate"):]
+	return strings.HasPrefix(rest, " ") || strings.HasPrefix(rest, "\t")
+}
+
+// counterfeiterArgs returns the arguments passed to counterfeiter by the
+// command in words, if the command runs counterfeiter. Directives that
+// themselves run the batch mode are skipped.
+func counterfeiterArgs(words []string) ([]string, bool) {
+	var args []string
+	switch {
+	case len(words) > 0 && isCounterfeiter(words[0]):
+		args = words[1:]
+	case len(words) > 2 && words[0] == "go" && words[1] == "run" && isCounterfeiter(words[2]):
+		args = words[3:]
+	default:
+		return nil, false
+	}
+	if len(args) > 0 && (args[0] == "generate" || args[0] == "verify") {
+		return nil, false
+	}
+	return args, true
+}
+
+func isCounterfeiter(command string) bool {
+	base := path.Base(filepath.ToSlash(command))
+	return strings.TrimSuffix(base, path.Ext(base)) == "counterfeiter"
+}
+
+// splitDirective splits the command of a go:generate directive into words the
+// same way go generate does: words are separated by spaces, double quoted
+// words are unquoted and $NAME variables are expanded.
+func splitDirective(line string, expand func(string) string) ([]string, error) {
+	var words []string
+	line = strings.TrimSpace(line)
+	for line != "" {
+		if line[0] == '"' {
+			end := 1
+			for ; end < len(line); end++ {
+				if line[end] == '\\' {
+					end++
+					continue
+				}
+				if line[end] == '"' {
+					break
+				}
+			}
+			if end >= len(line) {
+				return nil, fmt.Errorf("unterminated quoted string in go:generate directive")
+			}
+			word, err := strconv.Unquote(line[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted string in go:generate directive: %v", err)
+			}
+			words = append(words, os.Expand(word, expand))
+			line = strings.TrimSpace(line[end+1:])
+			continue
+		}
+		end := strings.IndexAny(line, " \t")
+		if end < 0 {
+			end = len(line)
+		}
+		words = append(words, os.Expand(line[:end], expand))
+		line = strings.TrimSpace(line[end:])
+	}
+	return words, nil
+}
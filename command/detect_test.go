@@ -0,0 +1,121 @@
+package command
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestDetect(t *testing.T) {
+	spec.Run(t, "Detect", testDetect, spec.Report(report.Terminal{}))
+}
+
+func testDetect(t *testing.T, when spec.G, it spec.S) {
+	it.Before(func() {
+		RegisterTestingT(t)
+	})
+
+	when("detecting the invocations in a package", func() {
+		var (
+			fixturesDir string
+			invocations []Invocation
+		)
+
+		it.Before(func() {
+			var err error
+			fixturesDir, err = filepath.Abs(filepath.Join("..", "fixtures"))
+			Expect(err).NotTo(HaveOccurred())
+			invocations, err = Detect(fixturesDir, ".")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it("finds every counterfeiter directive", func() {
+			var args [][]string
+			for i := range invocations {
+				args = append(args, invocations[i].Args)
+			}
+			Expect(args).To(ContainElement([]string{".", "Something"}))
+			Expect(args).To(ContainElement([]string{".", "FirstInterface"}))
+			Expect(args).To(ContainElement([]string{".", "SecondInterface"}))
+		})
+
+		it("records where each directive was found", func() {
+			for i := range invocations {
+				if invocations[i].Args[1] != "Something" {
+					continue
+				}
+				Expect(invocations[i].File).To(Equal(filepath.Join(fixturesDir, "something.go")))
+				Expect(invocations[i].Line).To(Equal(3))
+				Expect(invocations[i].Package).To(Equal("fixtures"))
+				Expect(invocations[i].WorkingDirectory).To(Equal(fixturesDir))
+			}
+		})
+
+		it("does not include the directives of other packages", func() {
+			for i := range invocations {
+				Expect(invocations[i].WorkingDirectory).To(Equal(fixturesDir))
+			}
+		})
+	})
+
+	when("splitting a directive", func() {
+		expand := func(name string) string {
+			if name == "GOFILE" {
+				return "file.go"
+			}
+			return ""
+		}
+
+		it("splits on whitespace", func() {
+			words, err := splitDirective(" counterfeiter  -o\tout.go . Something", expand)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(words).To(Equal([]string{"counterfeiter", "-o", "out.go", ".", "Something"}))
+		})
+
+		it("keeps quoted strings together", func() {
+			words, err := splitDirective(`counterfeiter -o "some dir/out.go" . Something`, expand)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(words).To(Equal([]string{"counterfeiter", "-o", "some dir/out.go", ".", "Something"}))
+		})
+
+		it("expands variables", func() {
+			words, err := splitDirective("counterfeiter $GOFILE Something", expand)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(words).To(Equal([]string{"counterfeiter", "file.go", "Something"}))
+		})
+
+		it("errors on an unterminated quoted string", func() {
+			_, err := splitDirective(`counterfeiter "Something`, expand)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	when("recognising counterfeiter commands", func() {
+		it("accepts the counterfeiter binary", func() {
+			args, ok := counterfeiterArgs([]string{"counterfeiter", ".", "Something"})
+			Expect(ok).To(BeTrue())
+			Expect(args).To(Equal([]string{".", "Something"}))
+		})
+
+		it("accepts go run", func() {
+			args, ok := counterfeiterArgs([]string{"go", "run", "github.com/maxbrunsfeld/counterfeiter", ".", "Something"})
+			Expect(ok).To(BeTrue())
+			Expect(args).To(Equal([]string{".", "Something"}))
+		})
+
+		it("ignores other commands", func() {
+			_, ok := counterfeiterArgs([]string{"stringer", "-type", "Something"})
+			Expect(ok).To(BeFalse())
+		})
+
+		it("ignores directives that run batch mode", func() {
+			_, ok := counterfeiterArgs([]string{"counterfeiter", "generate", "./..."})
+			Expect(ok).To(BeFalse())
+			_, ok = counterfeiterArgs([]string{"counterfeiter", "verify", "./..."})
+			Expect(ok).To(BeFalse())
+		})
+	})
+}
@@ -0,0 +1,593 @@
+// Package counterfeiter generates fakes in-process, as the counterfeiter
+// command does, for tools that embed it. Every option is explicit and errors
+// are returned, so Generate may be called concurrently.
+//
+//	results, err := counterfeiter.Generate(ctx, counterfeiter.Options{
+//		Dir:   "/src/myproject",
+//		Fakes: []counterfeiter.Fake{{SourceDir: "./mypackage", Interface: "MyInterface"}},
+//	})
+package counterfeiter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maxbrunsfeld/counterfeiter/arguments"
+	"github.com/maxbrunsfeld/counterfeiter/command"
+	"github.com/maxbrunsfeld/counterfeiter/diff"
+	"github.com/maxbrunsfeld/counterfeiter/generator"
+	"golang.org/x/tools/go/packages"
+)
+
+// Options configure Generate. The fakes to generate are the ones in Fakes and
+// Args, those declared in go:generate directives in Packages and those
+// declared in Manifest.
+type Options struct {
+	// Dir is the directory that relative paths and patterns are resolved
+	// against. It defaults to the current directory.
+	Dir string
+	// Fakes are the fakes to generate.
+	Fakes []Fake
+	// Args are the arguments of a single counterfeiter command, flags
+	// included, such as []string{"-o", "fakes", ".", "MyInterface"}. The
+	// -config and --output-format flags of the command are ignored.
+	Args []string
+	// Packages are the patterns of the packages whose counterfeiter
+	// go:generate directives are run, as counterfeiter generate does.
+	Packages []string
+	// Manifest is the path to a counterfeiter.yaml manifest whose fakes are
+	// generated.
+	Manifest string
+	// Check only compares the generated code with the files on disk. The
+	// Results tell which files are out of date.
+	Check bool
+	// DryRun generates the code without writing it.
+	DryRun bool
+	// OnResult, if set, is called with each Result as soon as it is known,
+	// including the Result of a file that failed.
+	OnResult func(Result)
+}
+
+// Fake describes a fake to generate, as the arguments of a single
+// counterfeiter command do.
+type Fake struct {
+	// SourceDir is the directory, or a file in the directory, containing the
+	// target. Either SourceDir or Package is set.
+	SourceDir string
+	// Package is the import path of the package containing the target, or of
+	// the package to generate an interface and shim for in package mode.
+	Package string
+	// Interface is the interface or function to fake. With FromStruct it is
+	// the name of the interface to extract. It is empty in package mode.
+	Interface string
+	// Interfaces are the interfaces of the package in SourceDir to fake,
+	// instead of Interface. All fakes every exported interface of it, or
+	// those whose names match the regular expression in Match. Output is
+	// then the directory each fake is written to, or the file they are all
+	// written to when it ends in .go.
+	Interfaces   []string
+	All          bool
+	Match        string
+	FakeName     string // defaults to Interface prefixed with Fake
+	Output       string // the file or directory to write the fake to
+	PackageMode  bool
+	ShimTypes    []string // in package mode, the struct types to also shim
+	Vars         []string // in package mode, the variables and constants to access, or *
+	ExcludeVars  []string // in package mode, the variables and constants not to access
+	FromStruct   string   // the struct type, such as sdk.Client, to extract Interface from
+	Adapter      bool     // with FromStruct, also generate an adapter
+	ParamNames   bool     // use the parameter names from the source
+	DeepCopyArgs bool     // record deep copies of the arguments
+	Strict       bool     // fail calls that nothing is stubbed for
+	Expectations bool     // generate ExpectX and Verify
+	SyncHooks    bool     // generate XBlockUntil, XWaitForCalls and XCallEvents
+	RecordCalls  bool     // generate XCallRecords and XResultsForCall
+	InjectErrors bool     // generate XFailsWith, XFailsOnCall, FailAll and FailRandomly
+	CallRecorder bool     // generate SetCallRecorder
+	ReturnsWhen  bool     // generate XReturnsWhen, XReturnsWhenMatches and XReturnsWhenStrict
+	Sequences    bool     // generate XReturnsSequence
+	Reset        bool     // generate Reset, ResetCalls and XReset
+	Delegate     bool     // generate NewXWithDelegate
+	ContextErrs  bool     // generate ReturnContextErrors
+	Template     string   // the template file or directory to generate the fake with
+	Style        string   // the style of test double to generate, testify or gomock
+}
+
+// arguments returns the flags and the arguments of the counterfeiter command
+// that generates the fake.
+func (f Fake) arguments() (arguments.Flags, []string) {
+	flags := arguments.Flags{
+		FakeName:     f.FakeName,
+		OutputPath:   f.Output,
+		PackageMode:  f.PackageMode,
+		ShimTypes:    f.ShimTypes,
+		Vars:         f.Vars,
+		ExcludeVars:  f.ExcludeVars,
+		FromStruct:   f.FromStruct,
+		Adapter:      f.Adapter,
+		ParamNames:   f.ParamNames,
+		DeepCopyArgs: f.DeepCopyArgs,
+		Strict:       f.Strict,
+		Expectations: f.Expectations,
+		SyncHooks:    f.SyncHooks,
+		RecordCalls:  f.RecordCalls,
+		InjectErrors: f.InjectErrors,
+		CallRecorder: f.CallRecorder,
+		ReturnsWhen:  f.ReturnsWhen,
+		Sequences:    f.Sequences,
+		Reset:        f.Reset,
+		Delegate:     f.Delegate,
+		ContextErrs:  f.ContextErrs,
+		All:          f.All,
+		Match:        f.Match,
+		Template:     f.Template,
+		Style:        f.Style,
+	}
+	switch {
+	case f.PackageMode && f.SourceDir != "":
+		return flags, []string{f.SourceDir}
+	case f.PackageMode:
+		return flags, []string{f.Package}
+	case f.FromStruct != "":
+		return flags, []string{f.Interface}
+	case len(f.Interfaces) > 0 || f.All:
+		return flags, append([]string{f.SourceDir}, f.Interfaces...)
+	case f.SourceDir != "":
+		return flags, []string{f.SourceDir, f.Interface}
+	default:
+		return flags, []string{f.Package + "." + f.Interface}
+	}
+}
+
+// Generate generates the fakes described by opts, loading each package that
+// contains a target only once. It returns a Result for each file, in the
+// order they were generated, and stops at the first failure, which is
+// returned as an *Error when it concerns a single file.
+func Generate(ctx context.Context, opts Options) ([]Result, error) {
+	dir := opts.Dir
+	if dir == "" {
+		var err error
+		dir, err = os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	targets, err := opts.targets(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	g := &generation{ctx: ctx, dir: dir, opts: opts}
+	err = g.run(targets)
+	return g.results, err
+}
+
+type target struct {
+	workingDir string
+	args       arguments.ParsedArguments
+}
+
+func (opts Options) targets(ctx context.Context, dir string) ([]target, error) {
+	var result []target
+	for i := range opts.Fakes {
+		flags, args := opts.Fakes[i].arguments()
+		parsed, err := arguments.Parse(flags, dir, args, filepath.EvalSymlinks, os.Stat)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, target{workingDir: dir, args: parsed})
+	}
+
+	if len(opts.Args) > 0 {
+		flags, args, err := arguments.ParseFlags(opts.Args)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := arguments.Parse(flags, dir, args, filepath.EvalSymlinks, os.Stat)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, target{workingDir: dir, args: parsed})
+	}
+
+	if len(opts.Packages) > 0 {
+		invocations, err := command.DetectContext(ctx, dir, opts.Packages...)
+		if err != nil {
+			return nil, err
+		}
+		for _, invocation := range invocations {
+			location := fmt.Sprintf("%s:%d", invocation.File, invocation.Line)
+			flags, args, err := arguments.ParseFlags(invocation.Args)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", location, err)
+			}
+			parsed, err := arguments.Parse(flags, invocation.WorkingDirectory, args, filepath.EvalSymlinks, os.Stat)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", location, err)
+			}
+			result = append(result, target{workingDir: invocation.WorkingDirectory, args: parsed})
+		}
+	}
+
+	if opts.Manifest != "" {
+		path := opts.Manifest
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		manifest, err := arguments.LoadManifest(path)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := manifest.Targets(filepath.EvalSymlinks, os.Stat)
+		if err != nil {
+			return nil, err
+		}
+		for i := range parsed {
+			result = append(result, target{workingDir: manifest.Dir(), args: parsed[i]})
+		}
+	}
+	return result, nil
+}
+
+// generation is a single call to Generate.
+type generation struct {
+	ctx     context.Context
+	dir     string
+	opts    Options
+	results []Result
+}
+
+// run generates the fakes for targets, loading the package graph once for
+// each package that contains a target.
+func (g *generation) run(targets []target) error {
+	var order []string
+	var fromStruct []target
+	groups := map[string][]target{}
+	for i := range targets {
+		t := targets[i]
+		t.args.Check = t.args.Check || g.opts.Check
+		if t.args.StructName != "" {
+			// The package of the fake only has the extracted interface once
+			// it is written, so it cannot be loaded up front.
+			fromStruct = append(fromStruct, t)
+			continue
+		}
+		key := t.args.PackagePath
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], t)
+	}
+
+	for _, key := range order {
+		targets := groups[key]
+		if err := g.ctx.Err(); err != nil {
+			return err
+		}
+		pkgs, err := generator.LoadPackagesContext(g.ctx, targets[0].workingDir, key)
+		if err != nil {
+			return g.fail(Result{TargetPackage: key}, err)
+		}
+		for i := range targets {
+			if targets[i].args.SeveralInterfaces() {
+				err = g.generateSeveral(targets[i], pkgs)
+			} else {
+				err = g.generate(targets[i], generator.WithPackages(pkgs))
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for i := range fromStruct {
+		if err := g.generate(fromStruct[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generate generates the fake for t. With --from-struct the extracted
+// interface is generated first, so that the fake can be generated for it.
+// When the interface is only checked or printed, and not written, the fake is
+// generated for its code in memory instead.
+func (g *generation) generate(t target, opts ...generator.Option) error {
+	args := t.args
+	opts = append(opts, generator.WithContext(g.ctx))
+
+	if args.StructName != "" {
+		err := g.emit(args.InterfaceName, args.InterfaceOutputPath, args, func() (*generator.Fake, error) {
+			return loadInterface(t.workingDir, args, opts...)
+		})
+		if err != nil {
+			return err
+		}
+		if args.Check || args.PrintToStdOut || g.opts.DryRun {
+			path, err := filepath.Abs(args.InterfaceOutputPath)
+			if err != nil {
+				return err
+			}
+			code := g.results[len(g.results)-1].Code
+			opts = append(opts, generator.WithOverlay(map[string][]byte{path: code}))
+		}
+	}
+	return g.emit(args.FakeImplName, args.OutputPath, args, func() (*generator.Fake, error) {
+		return loadFake(t.workingDir, args, opts...)
+	})
+}
+
+// generateSeveral generates the fakes of several interfaces of a package,
+// which is already loaded as pkgs, to a file each or all to the same file.
+func (g *generation) generateSeveral(t target, pkgs []*packages.Package) error {
+	names := t.args.InterfaceNames
+	if t.args.AllInterfaces {
+		all, err := generator.Interfaces(pkgs)
+		if err != nil {
+			return g.fail(Result{TargetPackage: t.args.PackagePath, OutputPath: t.args.OutputPath}, err)
+		}
+		pattern := regexp.MustCompile(t.args.InterfacePattern)
+		for _, name := range all {
+			if pattern.MatchString(name) {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 && t.args.InterfacePattern != "" {
+			return g.fail(Result{TargetPackage: t.args.PackagePath, OutputPath: t.args.OutputPath}, fmt.Errorf("no exported interface of %s matches %q", pkgs[0].PkgPath, t.args.InterfacePattern))
+		} else if len(names) == 0 {
+			return g.fail(Result{TargetPackage: t.args.PackagePath, OutputPath: t.args.OutputPath}, fmt.Errorf("%s has no exported interfaces to fake", pkgs[0].PkgPath))
+		}
+	}
+
+	if !t.args.SingleFile() {
+		for _, name := range names {
+			if err := g.generate(target{workingDir: t.workingDir, args: t.args.ForInterface(name)}, generator.WithPackages(pkgs)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	opts := []generator.Option{generator.WithPackages(pkgs), generator.WithContext(g.ctx)}
+	var fakeNames []string
+	var loads []func() (*generator.Fake, error)
+	for _, name := range names {
+		args := t.args.ForInterface(name)
+		fakeNames = append(fakeNames, args.FakeImplName)
+		loads = append(loads, func() (*generator.Fake, error) {
+			return loadFake(t.workingDir, args, opts...)
+		})
+	}
+	return g.emit(strings.Join(fakeNames, ", "), t.args.OutputPath, t.args, loads...)
+}
+
+// emit generates the code for the Fakes returned by loads and writes it to
+// outputPath, or only compares it with the file there when checking, and
+// records the Result. Several fakes are written to the file together.
+func (g *generation) emit(name string, outputPath string, args arguments.ParsedArguments, loads ...func() (*generator.Fake, error)) error {
+	if err := g.ctx.Err(); err != nil {
+		return err
+	}
+	start := time.Now()
+	result := Result{FakeName: name, OutputPath: outputPath}
+
+	var fakes []*generator.Fake
+	var files [][]byte
+	for _, load := range loads {
+		f, err := load()
+		if err != nil {
+			return g.fail(result, err)
+		}
+		fakes = append(fakes, f)
+		result = resultFor(outputPath, fakes...)
+		b, err := f.Generate(true)
+		if err != nil {
+			return g.fail(result, err)
+		}
+		files = append(files, b)
+	}
+	b, err := combine(files)
+	if err != nil {
+		return g.fail(result, err)
+	}
+	code, err := format.Source(b)
+	if err != nil {
+		return g.fail(result, err)
+	}
+	result.Code = code
+	result.Imports = importsOf(code)
+
+	existing, err := ioutil.ReadFile(outputPath)
+	if err != nil && !os.IsNotExist(err) {
+		return g.fail(result, fmt.Errorf("Couldn't read fake file - %v", err))
+	}
+	result.New = os.IsNotExist(err)
+	result.Changed = result.New || string(existing) != string(code)
+
+	switch {
+	case args.Check:
+		rel := g.rel(outputPath)
+		result.Diff = diff.Unified(rel, rel+" (generated)", string(existing), string(code))
+	case g.opts.DryRun || args.PrintToStdOut:
+	default:
+		if err := writeCode(code, outputPath); err != nil {
+			return g.fail(result, err)
+		}
+	}
+	result.DurationMS = float64(time.Since(start)) / float64(time.Millisecond)
+	g.report(result)
+	return nil
+}
+
+func (g *generation) report(result Result) {
+	g.results = append(g.results, result)
+	if g.opts.OnResult != nil {
+		g.opts.OnResult(result)
+	}
+}
+
+// fail records result as failed with err, and returns the *Error.
+func (g *generation) fail(result Result, err error) error {
+	result.Error = NewError(err)
+	g.report(result)
+	return result.Error
+}
+
+// rel is path relative to the directory of the generation, for messages.
+func (g *generation) rel(path string) string {
+	rel, err := filepath.Rel(g.dir, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
+
+func loadFake(workingDir string, args arguments.ParsedArguments, opts ...generator.Option) (*generator.Fake, error) {
+	mode := generator.InterfaceOrFunction
+	if args.GenerateInterfaceAndShimFromPackageDirectory {
+		mode = generator.Package
+	}
+	if args.UseParamNames {
+		opts = append(opts, generator.WithParamNames())
+	}
+	if args.DeepCopyArgs {
+		opts = append(opts, generator.WithDeepCopyArgs())
+	}
+	if args.Strict {
+		opts = append(opts, generator.WithStrict())
+	}
+	if args.Expectations {
+		opts = append(opts, generator.WithExpectations())
+	}
+	if args.SyncHooks {
+		opts = append(opts, generator.WithSyncHooks())
+	}
+	if args.RecordCalls {
+		opts = append(opts, generator.WithRecordCalls())
+	}
+	if args.InjectErrors {
+		opts = append(opts, generator.WithInjectErrors())
+	}
+	if args.CallRecorder {
+		opts = append(opts, generator.WithCallRecorder())
+	}
+	if args.ReturnsWhen {
+		opts = append(opts, generator.WithReturnsWhen())
+	}
+	if args.Sequences {
+		opts = append(opts, generator.WithReturnsSequence())
+	}
+	if args.Reset {
+		opts = append(opts, generator.WithReset())
+	}
+	if args.Delegate {
+		opts = append(opts, generator.WithDelegate())
+	}
+	if args.ContextErrs {
+		opts = append(opts, generator.WithContextErrors())
+	}
+	if len(args.ShimTypes) > 0 {
+		opts = append(opts, generator.WithShimTypes(args.ShimTypes...))
+	}
+	if len(args.Vars) > 0 {
+		opts = append(opts, generator.WithPackageVars(args.Vars...), generator.WithoutPackageVars(args.ExcludeVars...))
+	}
+	if args.TemplatePath != "" {
+		opts = append(opts, generator.WithTemplate(args.TemplatePath))
+	}
+	if args.Style != "" {
+		opts = append(opts, generator.WithStyle(args.Style))
+	}
+	return generator.NewFake(mode, args.InterfaceName, args.PackagePath, args.FakeImplName, args.DestinationPackageName, workingDir, opts...)
+}
+
+// loadInterface loads the interface named by args from the exported methods
+// of the struct given with --from-struct.
+func loadInterface(workingDir string, args arguments.ParsedArguments, opts ...generator.Option) (*generator.Fake, error) {
+	if args.UseParamNames {
+		opts = append(opts, generator.WithParamNames())
+	}
+	if args.Adapter {
+		opts = append(opts, generator.WithAdapter())
+	}
+	return generator.NewFake(generator.Struct, args.StructName, args.StructPackagePath, args.InterfaceName, args.InterfacePackageName, workingDir, opts...)
+}
+
+// combine joins the code generated for several fakes of the same destination
+// package into a single file, which imports everything they import. Blank and
+// dot imports are kept once for each path they import.
+func combine(files [][]byte) ([]byte, error) {
+	if len(files) == 1 {
+		return files[0], nil
+	}
+	var header, imports, decls bytes.Buffer
+	importedAs := map[string]string{}
+	for i, code := range files {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "", code, parser.ImportsOnly)
+		if err != nil {
+			return nil, err
+		}
+		end := fset.Position(file.Name.End()).Offset
+		if i == 0 {
+			header.Write(code[:end])
+		}
+		for _, decl := range file.Decls {
+			if offset := fset.Position(decl.End()).Offset; offset > end {
+				end = offset
+			}
+		}
+		for _, spec := range file.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				return nil, err
+			}
+			name := path.Base(importPath)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			key := name
+			if name == "." || name == "_" {
+				key = name + " " + importPath
+			}
+			if other, ok := importedAs[key]; ok && other != importPath {
+				return nil, fmt.Errorf("cannot write the fakes to one file, because they import both %s and %s as %s", other, importPath, name)
+			} else if ok {
+				continue
+			}
+			importedAs[key] = importPath
+			fmt.Fprintf(&imports, "\t%s %s\n", name, spec.Path.Value)
+		}
+		decls.Write(code[end:])
+	}
+	return []byte(header.String() + "\n\nimport (\n" + imports.String() + ")\n" + decls.String()), nil
+}
+
+func writeCode(code []byte, outputPath string) error {
+	os.MkdirAll(filepath.Dir(outputPath), 0777)
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("Couldn't create fake file - %v", err)
+	}
+	defer file.Close()
+
+	_, err = file.Write(code)
+	if err != nil {
+		return fmt.Errorf("Couldn't write to fake file - %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package counterfeiter
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestCombine(t *testing.T) {
+	spec.Run(t, "Combine", testCombine, spec.Report(report.Terminal{}))
+}
+
+func testCombine(t *testing.T, when spec.G, it spec.S) {
+	it.Before(func() {
+		RegisterTestingT(t)
+	})
+
+	it("imports everything the fakes import once", func() {
+		b, err := combine([][]byte{
+			[]byte("package fakes\n\nimport (\n\t\"io\"\n\t_ \"embed\"\n\t. \"strings\"\n)\n\nvar _ io.Reader = NewReader(\"\")\n"),
+			[]byte("package fakes\n\nimport (\n\t\"io\"\n\t_ \"embed\"\n\t_ \"time/tzdata\"\n\t. \"strings\"\n\t. \"bytes\"\n)\n\nvar _ io.Writer = new(Buffer)\n"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("package fakes\n\nimport (\n" +
+			"\tio \"io\"\n" +
+			"\t_ \"embed\"\n" +
+			"\t. \"strings\"\n" +
+			"\t_ \"time/tzdata\"\n" +
+			"\t. \"bytes\"\n" +
+			")\n\n\nvar _ io.Reader = NewReader(\"\")\n\n\nvar _ io.Writer = new(Buffer)\n"))
+	})
+
+	it("rejects fakes that import different packages under the same name", func() {
+		_, err := combine([][]byte{
+			[]byte("package fakes\n\nimport \"math/rand\"\n\nvar _ = rand.Int\n"),
+			[]byte("package fakes\n\nimport \"crypto/rand\"\n\nvar _ = rand.Reader\n"),
+		})
+		Expect(err).To(MatchError("cannot write the fakes to one file, because they import both math/rand and crypto/rand as rand"))
+	})
+}
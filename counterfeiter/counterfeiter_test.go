@@ -0,0 +1,222 @@
+package counterfeiter_test
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/maxbrunsfeld/counterfeiter/counterfeiter"
+)
+
+func TestGenerate(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	spec.Run(t, "Generate", testGenerate, spec.Report(report.Terminal{}))
+}
+
+func testGenerate(t *testing.T, when spec.G, it spec.S) {
+	var (
+		dir    string
+		output string
+		opts   counterfeiter.Options
+	)
+
+	it.Before(func() {
+		RegisterTestingT(t)
+		var err error
+		dir, err = ioutil.TempDir("", "counterfeiter-generate")
+		Expect(err).NotTo(HaveOccurred())
+		output = filepath.Join(dir, "fakes", "fake_something_else.go")
+		opts = counterfeiter.Options{
+			Dir: filepath.Join("..", "fixtures"),
+			Fakes: []counterfeiter.Fake{
+				{SourceDir: ".", Interface: "SomethingElse", Output: output},
+			},
+		}
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	it("writes the fakes and describes them", func() {
+		results, err := counterfeiter.Generate(context.Background(), opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].TargetPackage).To(Equal("github.com/maxbrunsfeld/counterfeiter/fixtures"))
+		Expect(results[0].Interface).To(Equal("SomethingElse"))
+		Expect(results[0].FakeName).To(Equal("FakeSomethingElse"))
+		Expect(results[0].OutputPath).To(Equal(output))
+		Expect(results[0].Methods).To(Equal([]string{"ReturnStuff"}))
+		Expect(results[0].Imports).To(ContainElement("sync"))
+		Expect(results[0].Changed).To(BeTrue())
+		Expect(results[0].New).To(BeTrue())
+
+		code, err := ioutil.ReadFile(output)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(code).To(Equal(results[0].Code))
+	})
+
+	it("only compares the fakes with the files on disk when checking", func() {
+		opts.Check = true
+		results, err := counterfeiter.Generate(context.Background(), opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results[0].Changed).To(BeTrue())
+		Expect(results[0].Diff).To(ContainSubstring("+type FakeSomethingElse struct {"))
+		Expect(output).NotTo(BeAnExistingFile())
+
+		opts.Check = false
+		_, err = counterfeiter.Generate(context.Background(), opts)
+		Expect(err).NotTo(HaveOccurred())
+
+		opts.Check = true
+		results, err = counterfeiter.Generate(context.Background(), opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results[0].Changed).To(BeFalse())
+		Expect(results[0].Diff).To(BeEmpty())
+	})
+
+	it("does not write the fakes in a dry run", func() {
+		opts.DryRun = true
+		results, err := counterfeiter.Generate(context.Background(), opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(results[0].Code)).To(ContainSubstring("type FakeSomethingElse struct {"))
+		Expect(output).NotTo(BeAnExistingFile())
+	})
+
+	it("fakes an interface extracted from a struct without writing it", func() {
+		opts.Fakes = []counterfeiter.Fake{{FromStruct: "strings.Builder", Interface: "Builder", Output: output}}
+		extracted := filepath.Join("..", "fixtures", "builder.go")
+
+		opts.DryRun = true
+		results, err := counterfeiter.Generate(context.Background(), opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(2))
+		Expect(string(results[0].Code)).To(ContainSubstring("type Builder interface {"))
+		Expect(string(results[1].Code)).To(ContainSubstring("type FakeBuilder struct {"))
+		Expect(extracted).NotTo(BeAnExistingFile())
+
+		opts.DryRun = false
+		opts.Check = true
+		results, err = counterfeiter.Generate(context.Background(), opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results[1].Diff).To(ContainSubstring("+type FakeBuilder struct {"))
+		Expect(extracted).NotTo(BeAnExistingFile())
+		Expect(output).NotTo(BeAnExistingFile())
+	})
+
+	it("accepts the arguments of a counterfeiter command", func() {
+		opts.Fakes = nil
+		opts.Args = []string{"-o", output, "--fake-name", "Other", ".", "SomethingElse"}
+		results, err := counterfeiter.Generate(context.Background(), opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results[0].FakeName).To(Equal("Other"))
+		Expect(output).To(BeAnExistingFile())
+	})
+
+	it("can be called concurrently", func() {
+		opts.DryRun = true
+		var wg sync.WaitGroup
+		errs := make([]error, 4)
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, errs[i] = counterfeiter.Generate(context.Background(), opts)
+			}(i)
+		}
+		wg.Wait()
+		for i := range errs {
+			Expect(errs[i]).NotTo(HaveOccurred())
+		}
+	})
+
+	it("reports every result as it is known", func() {
+		var reported []counterfeiter.Result
+		opts.OnResult = func(result counterfeiter.Result) {
+			reported = append(reported, result)
+		}
+		results, err := counterfeiter.Generate(context.Background(), opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reported).To(Equal(results))
+	})
+
+	when("several interfaces are given", func() {
+		it.Before(func() {
+			opts.Fakes[0] = counterfeiter.Fake{SourceDir: ".", Interfaces: []string{"SomethingElse", "Resettable"}, Output: filepath.Join(dir, "fakes")}
+		})
+
+		it("writes a fake for each to the output directory", func() {
+			results, err := counterfeiter.Generate(context.Background(), opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(HaveLen(2))
+			Expect(results[0].FakeName).To(Equal("FakeSomethingElse"))
+			Expect(results[0].OutputPath).To(Equal(output))
+			Expect(results[1].FakeName).To(Equal("FakeResettable"))
+			Expect(results[1].OutputPath).To(Equal(filepath.Join(dir, "fakes", "fake_resettable.go")))
+			Expect(output).To(BeAnExistingFile())
+			Expect(results[1].OutputPath).To(BeAnExistingFile())
+		})
+
+		it("writes them all to the output file", func() {
+			opts.Fakes[0].Output = filepath.Join(dir, "fakes", "fakes.go")
+			results, err := counterfeiter.Generate(context.Background(), opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].Interface).To(Equal("SomethingElse, Resettable"))
+			Expect(results[0].FakeName).To(Equal("FakeSomethingElse, FakeResettable"))
+			Expect(results[0].Methods).To(ContainElement("Resettable.Size"))
+			Expect(string(results[0].Code)).To(ContainSubstring("type FakeSomethingElse struct {"))
+			Expect(string(results[0].Code)).To(ContainSubstring("type FakeResettable struct {"))
+			Expect(string(results[0].Code)).To(HavePrefix("// Code generated by counterfeiter. DO NOT EDIT.\npackage fakes\n"))
+		})
+
+		it("fakes every exported interface that matches with All", func() {
+			opts.Fakes[0] = counterfeiter.Fake{SourceDir: ".", All: true, Match: "^(First|Second)Interface$", Output: filepath.Join(dir, "fakes")}
+			results, err := counterfeiter.Generate(context.Background(), opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(HaveLen(2))
+			Expect(results[0].FakeName).To(Equal("FakeFirstInterface"))
+			Expect(results[1].FakeName).To(Equal("FakeSecondInterface"))
+		})
+
+		it("fails when no interface matches", func() {
+			opts.Fakes[0] = counterfeiter.Fake{SourceDir: ".", All: true, Match: "^Nothing$", Output: filepath.Join(dir, "fakes")}
+			_, err := counterfeiter.Generate(context.Background(), opts)
+			Expect(err).To(MatchError(`no exported interface of github.com/maxbrunsfeld/counterfeiter/fixtures matches "^Nothing$"`))
+		})
+	})
+
+	when("a fake cannot be generated", func() {
+		it("returns the failure with the result of the file", func() {
+			opts.Fakes[0].Interface = "NotAnInterface"
+			results, err := counterfeiter.Generate(context.Background(), opts)
+			Expect(err).To(MatchError("cannot find package with target: NotAnInterface"))
+			Expect(err).To(BeAssignableToTypeOf(&counterfeiter.Error{}))
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].FakeName).To(Equal("FakeNotAnInterface"))
+			Expect(results[0].Error).To(Equal(err))
+		})
+
+		it("returns invalid arguments as an error", func() {
+			opts.Fakes[0].FakeName = "not-valid"
+			_, err := counterfeiter.Generate(context.Background(), opts)
+			Expect(err).To(MatchError(`"not-valid" is not a valid name for a fake`))
+		})
+
+		it("stops when the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			results, err := counterfeiter.Generate(ctx, opts)
+			Expect(err).To(Equal(context.Canceled))
+			Expect(results).To(BeEmpty())
+		})
+	})
+}
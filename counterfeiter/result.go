@@ -0,0 +1,111 @@
+package counterfeiter
+
+import (
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/maxbrunsfeld/counterfeiter/generator"
+	"golang.org/x/tools/go/packages"
+)
+
+// Result describes a file written, or checked, by Generate. The counterfeiter
+// command prints each one as a line of JSON with --output-format=json.
+type Result struct {
+	TargetPackage string   `json:"target_package,omitempty"`
+	Interface     string   `json:"interface,omitempty"`
+	FakeName      string   `json:"fake_name,omitempty"`
+	OutputPath    string   `json:"output_path,omitempty"`
+	Imports       []string `json:"imports,omitempty"`
+	Methods       []string `json:"methods,omitempty"`
+	Changed       bool     `json:"changed"`        // whether the file was, or with Check would be, changed
+	New           bool     `json:"new,omitempty"`  // whether the file did not exist yet
+	Diff          string   `json:"diff,omitempty"` // with Check, how the file on disk differs
+	DurationMS    float64  `json:"duration_ms"`
+	Error         *Error   `json:"error,omitempty"`
+	Code          []byte   `json:"-"` // the generated code
+}
+
+// Error is the failure to generate a file. Generate returns it, after adding
+// it to the Result for the file.
+type Error struct {
+	Message      string        `json:"message"`
+	LoaderErrors []LoaderError `json:"loader_errors,omitempty"` // when the packages could not be loaded
+	Err          error         `json:"-"`                       // the error that caused the failure
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// LoaderError is an error reported by the package loader.
+type LoaderError struct {
+	Pos  string `json:"pos,omitempty"`
+	Msg  string `json:"msg"`
+	Kind string `json:"kind"`
+}
+
+var loaderErrorKinds = map[packages.ErrorKind]string{
+	packages.UnknownError: "unknown",
+	packages.ListError:    "list",
+	packages.ParseError:   "parse",
+	packages.TypeError:    "type",
+}
+
+// NewError returns the Error for err, with the errors of the package loader
+// attached when err is a *generator.LoadError.
+func NewError(err error) *Error {
+	result := &Error{Message: err.Error(), Err: err}
+	if loadErr, ok := err.(*generator.LoadError); ok {
+		for _, e := range loadErr.Errors {
+			result.LoaderErrors = append(result.LoaderErrors, LoaderError{
+				Pos:  e.Pos,
+				Msg:  e.Msg,
+				Kind: loaderErrorKinds[e.Kind],
+			})
+		}
+	}
+	return result
+}
+
+// resultFor describes the file at outputPath that has the code of fakes. When
+// there are several, their names are joined with commas and their methods are
+// qualified with the names of their interfaces.
+func resultFor(outputPath string, fakes ...*generator.Fake) Result {
+	result := Result{
+		TargetPackage: fakes[0].TargetPackage,
+		OutputPath:    outputPath,
+	}
+	var interfaces, fakeNames []string
+	for _, f := range fakes {
+		interfaces = append(interfaces, f.TargetName)
+		fakeNames = append(fakeNames, f.Name)
+		for i := range f.Methods {
+			if len(fakes) > 1 {
+				result.Methods = append(result.Methods, f.TargetName+"."+f.Methods[i].Name)
+			} else {
+				result.Methods = append(result.Methods, f.Methods[i].Name)
+			}
+		}
+	}
+	result.Interface = strings.Join(interfaces, ", ")
+	result.FakeName = strings.Join(fakeNames, ", ")
+	return result
+}
+
+// importsOf lists the import paths of the generated code, which only has the
+// imports of the Fake that it uses.
+func importsOf(code []byte) []string {
+	file, err := parser.ParseFile(token.NewFileSet(), "", code, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+	var result []string
+	for _, spec := range file.Imports {
+		if path, err := strconv.Unquote(spec.Path.Value); err == nil {
+			result = append(result, path)
+		}
+	}
+	return result
+}
@@ -0,0 +1,52 @@
+package counterfeiter
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/maxbrunsfeld/counterfeiter/generator"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestResult(t *testing.T) {
+	spec.Run(t, "Result", testResult, spec.Report(report.Terminal{}))
+}
+
+func testResult(t *testing.T, when spec.G, it spec.S) {
+	it.Before(func() {
+		RegisterTestingT(t)
+	})
+
+	it("attaches the errors of the package loader", func() {
+		err := &generator.LoadError{Errors: []packages.Error{
+			{Pos: "a.go:3:24", Msg: "undefined: Missing", Kind: packages.TypeError},
+			{Msg: "no Go files", Kind: packages.ListError},
+		}}
+		Expect(NewError(err)).To(Equal(&Error{
+			Message: "a.go:3:24: undefined: Missing",
+			LoaderErrors: []LoaderError{
+				{Pos: "a.go:3:24", Msg: "undefined: Missing", Kind: "type"},
+				{Msg: "no Go files", Kind: "list"},
+			},
+			Err: err,
+		}))
+
+		plain := errors.New("boom")
+		Expect(NewError(plain)).To(Equal(&Error{Message: "boom", Err: plain}))
+	})
+
+	it("lists the imports of the generated code", func() {
+		code := []byte("package fakes\n\nimport (\n\t\"sync\"\n\n\tfoo \"example.com/foo\"\n)\n")
+		Expect(importsOf(code)).To(Equal([]string{"sync", "example.com/foo"}))
+	})
+
+	it("encodes a result as JSON, without the code", func() {
+		b, err := json.Marshal(Result{FakeName: "FakeThing", Methods: []string{"Do"}, Changed: true, Code: []byte("package fakes")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`{"fake_name":"FakeThing","methods":["Do"],"changed":true,"duration_ms":0}`))
+	})
+}
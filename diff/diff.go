@@ -0,0 +1,211 @@
+// Package diff computes line based unified diffs, which are used to show how
+// a fake on disk differs from the one counterfeiter would generate.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change.
+const context = 3
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+// op is a single line of an edit script. a and b are the positions of the line
+// in the old and new text respectively.
+type op struct {
+	kind opKind
+	a, b int
+}
+
+// Unified returns the unified diff that turns from into to, labelling them
+// fromName and toName. It returns "" when the texts are equal.
+func Unified(fromName string, toName string, from string, to string) string {
+	if from == to {
+		return ""
+	}
+	a, b := splitLines(from), splitLines(to)
+	ops := editScript(a, b)
+
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range hunks(ops) {
+		writeHunk(out, ops[h[0]:h[1]], a, b)
+	}
+	return out.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// editScript finds a shortest edit script from a to b with Myers' algorithm.
+func editScript(a, b []string) []op {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return trivialScript(n, m)
+	}
+
+	size := n + m
+	v := make([]int, 2*size+2)
+	var trace [][]int
+	for d := 0; d <= size; d++ {
+		snapshot := make([]int, len(v))
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+		found := false
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[size+k-1] < v[size+k+1]) {
+				x = v[size+k+1]
+			} else {
+				x = v[size+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[size+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+		if found {
+			break
+		}
+	}
+
+	var reversed []op
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[size+k-1] < v[size+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[size+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, op{kind: opEqual, a: x, b: y})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			reversed = append(reversed, op{kind: opInsert, a: x, b: prevY})
+		} else {
+			reversed = append(reversed, op{kind: opDelete, a: prevX, b: y})
+		}
+		x, y = prevX, prevY
+	}
+
+	ops := make([]op, len(reversed))
+	for i := range reversed {
+		ops[len(reversed)-1-i] = reversed[i]
+	}
+	return ops
+}
+
+func trivialScript(n, m int) []op {
+	var ops []op
+	for i := 0; i < n; i++ {
+		ops = append(ops, op{kind: opDelete, a: i})
+	}
+	for j := 0; j < m; j++ {
+		ops = append(ops, op{kind: opInsert, b: j})
+	}
+	return ops
+}
+
+// hunks groups the changes in ops, with their context, into [start, end)
+// ranges of ops. Changes separated by no more than twice the context are
+// shown in the same hunk.
+func hunks(ops []op) [][2]int {
+	var result [][2]int
+	for i := 0; i < len(ops); {
+		if ops[i].kind == opEqual {
+			i++
+			continue
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i + 1
+		for j := end; j < len(ops); j++ {
+			if ops[j].kind == opEqual {
+				continue
+			}
+			if j-end > 2*context {
+				break
+			}
+			end = j + 1
+		}
+		i = end
+		end = end + context
+		if end > len(ops) {
+			end = len(ops)
+		}
+		result = append(result, [2]int{start, end})
+	}
+	return result
+}
+
+func writeHunk(out *strings.Builder, ops []op, a, b []string) {
+	aStart, bStart := ops[0].a, ops[0].b
+	aLen, bLen := 0, 0
+	for i := range ops {
+		if ops[i].kind != opInsert {
+			aLen++
+		}
+		if ops[i].kind != opDelete {
+			bLen++
+		}
+	}
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(aStart, aLen), hunkRange(bStart, bLen))
+	for i := range ops {
+		line := ""
+		switch ops[i].kind {
+		case opInsert:
+			line = b[ops[i].b]
+		default:
+			line = a[ops[i].a]
+		}
+		out.WriteByte(byte(ops[i].kind))
+		out.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
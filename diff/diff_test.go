@@ -0,0 +1,102 @@
+package diff
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestDiff(t *testing.T) {
+	spec.Run(t, "Diff", testDiff, spec.Report(report.Terminal{}))
+}
+
+func testDiff(t *testing.T, when spec.G, it spec.S) {
+	it.Before(func() {
+		RegisterTestingT(t)
+	})
+
+	it("is empty when the texts are equal", func() {
+		Expect(Unified("a", "b", "one\ntwo\n", "one\ntwo\n")).To(BeEmpty())
+	})
+
+	it("shows a changed line with its context", func() {
+		from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+		to := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n"
+		Expect(Unified("old", "new", from, to)).To(Equal(`--- old
++++ new
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`))
+	})
+
+	it("shows every line as added when the old text is empty", func() {
+		Expect(Unified("old", "new", "", "one\ntwo\n")).To(Equal(`--- old
++++ new
+@@ -0,0 +1,2 @@
++one
++two
+`))
+	})
+
+	it("shows every line as removed when the new text is empty", func() {
+		Expect(Unified("old", "new", "one\n", "")).To(Equal(`--- old
++++ new
+@@ -1 +0,0 @@
+-one
+`))
+	})
+
+	it("splits changes that are far apart into separate hunks", func() {
+		from := "a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n"
+		to := "A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n"
+		Expect(Unified("old", "new", from, to)).To(Equal(`--- old
++++ new
+@@ -1,4 +1,4 @@
+-a
++A
+ 1
+ 2
+ 3
+@@ -7,4 +7,4 @@
+ 6
+ 7
+ 8
+-b
++B
+`))
+	})
+
+	it("merges changes that are close together into one hunk", func() {
+		from := "a\n1\n2\nb\n"
+		to := "A\n1\n2\nB\n"
+		Expect(Unified("old", "new", from, to)).To(Equal(`--- old
++++ new
+@@ -1,4 +1,4 @@
+-a
++A
+ 1
+ 2
+-b
++B
+`))
+	})
+
+	it("marks a missing newline at the end of the text", func() {
+		Expect(Unified("old", "new", "one\n", "one")).To(Equal(`--- old
++++ new
+@@ -1 +1 @@
+-one
++one
+\ No newline at end of file
+`))
+	})
+}
@@ -0,0 +1,10 @@
+package fixtures
+
+import "context"
+
+//go:generate counterfeiter --context-errors . ContextAware
+type ContextAware interface {
+	Fetch(ctx context.Context, key string) ([]byte, error)
+	Close(context.Context) error
+	Name() string
+}
@@ -0,0 +1,16 @@
+package fixtures
+
+import "io"
+
+type Settings struct {
+	Name   string
+	Tags   []string
+	Limits map[string]int
+}
+
+//go:generate counterfeiter --deep-copy-args . DeepCopied
+type DeepCopied interface {
+	Save(key string, settings *Settings) error
+	Merge(values map[string][]string, grid [2][]int)
+	Batch(settings []*Settings, writer io.Writer, keys ...string)
+}
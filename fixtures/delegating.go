@@ -0,0 +1,7 @@
+package fixtures
+
+//go:generate counterfeiter --delegate --reset --returns-when . Delegating
+type Delegating interface {
+	DoThings(string, uint64) (int, error)
+	DoNothing()
+}
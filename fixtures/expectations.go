@@ -0,0 +1,14 @@
+package fixtures
+
+//go:generate counterfeiter --expectations . Expected
+type Expected interface {
+	DoThings(name string, count uint64) (int, error)
+	Ready() bool
+	Close()
+}
+
+//go:generate counterfeiter --expectations --strict . StrictlyExpected
+type StrictlyExpected interface {
+	DoThings(name string, count uint64) (int, error)
+	Close()
+}
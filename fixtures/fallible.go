@@ -0,0 +1,7 @@
+package fixtures
+
+//go:generate counterfeiter --inject-errors --reset . Fallible
+type Fallible interface {
+	DoThings(string, uint64) (int, error)
+	DoNothing()
+}
@@ -0,0 +1,35 @@
+package fixtures
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type User struct {
+	Name string
+}
+
+type Reader[T any] interface {
+	Get(id string) (T, error)
+}
+
+//go:generate counterfeiter . Repository
+//go:generate counterfeiter . Repository[User]
+type Repository[T any] interface {
+	Reader[T]
+	List(filter func(T) bool) []T
+	Save(items ...T) error
+}
+
+//go:generate counterfeiter . Cache
+//go:generate counterfeiter . Cache[string,*bytes.Buffer]
+type Cache[K ~string | ~int, V fmt.Stringer] interface {
+	Get(K) (V, bool)
+	Put(K, V)
+	Keys() []K
+	Dump(*bytes.Buffer) error
+}
+
+//go:generate counterfeiter . Mapper
+//go:generate counterfeiter . Mapper[string,int]
+type Mapper[T, U any] func(T) (U, error)
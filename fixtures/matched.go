@@ -0,0 +1,11 @@
+package fixtures
+
+//go:generate counterfeiter --returns-when . Matched
+type Matched interface {
+	DoThings(string, uint64) (int, error)
+	DoVarArgs(int, ...string) int
+	DoNothing()
+}
+
+//go:generate counterfeiter --returns-when . MatchedFunc
+type MatchedFunc func(string, map[string]interface{}) string
@@ -0,0 +1,13 @@
+package fixtures
+
+import (
+	"context"
+	"net/http"
+)
+
+//go:generate counterfeiter --param-names . NamedParams
+type NamedParams interface {
+	DoThings(name string, count uint64) (n int, err error)
+	Shadows(ctx context.Context, fake string, http *http.Request, len int) (ret error)
+	Unnamed(_ string, _ int, data []byte, URL string, items ...string) (_ bool, dataCopy []byte)
+}
@@ -0,0 +1,10 @@
+package fixtures
+
+//go:generate counterfeiter --call-recorder . Ordered
+type Ordered interface {
+	DoThings(string, uint64) (int, error)
+	DoNothing()
+}
+
+//go:generate counterfeiter --call-recorder . OrderedFunc
+type OrderedFunc func(string, map[string]interface{}) string
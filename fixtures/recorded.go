@@ -0,0 +1,7 @@
+package fixtures
+
+//go:generate counterfeiter --record-calls --reset . Recorded
+type Recorded interface {
+	DoThings(string, uint64) (int, error)
+	DoNothing()
+}
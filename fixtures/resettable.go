@@ -0,0 +1,7 @@
+package fixtures
+
+//go:generate counterfeiter --reset . Resettable
+type Resettable interface {
+	Reset()
+	Size() int
+}
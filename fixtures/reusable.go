@@ -0,0 +1,10 @@
+package fixtures
+
+//go:generate counterfeiter --reset --returns-when --returns-sequence . Reusable
+type Reusable interface {
+	DoThings(string, uint64) (int, error)
+	DoNothing()
+}
+
+//go:generate counterfeiter --reset . ReusableFunc
+type ReusableFunc func(string, map[string]interface{}) string
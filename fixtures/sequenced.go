@@ -0,0 +1,10 @@
+package fixtures
+
+//go:generate counterfeiter --returns-sequence . Sequenced
+type Sequenced interface {
+	DoThings(string, uint64) (int, error)
+	DoNothing()
+}
+
+//go:generate counterfeiter --returns-sequence . SequencedFunc
+type SequencedFunc func(string, map[string]interface{}) string
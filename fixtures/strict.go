@@ -0,0 +1,14 @@
+package fixtures
+
+//go:generate counterfeiter --strict --returns-when --returns-sequence . Strict
+type Strict interface {
+	Lookup(key string) (string, error)
+	Count() int
+	Forget(key string)
+}
+
+//go:generate counterfeiter --strict --returns-sequence . StrictFunc
+type StrictFunc func(name string) bool
+
+//go:generate counterfeiter --strict . StrictVoidFunc
+type StrictVoidFunc func(name string)
@@ -0,0 +1,9 @@
+package fixtures
+
+//go:generate counterfeiter --style=testify --fake-name TestifyStyled . Styled
+//go:generate counterfeiter --style=gomock --fake-name GomockStyled . Styled
+type Styled interface {
+	DoThings(string, uint64) (int, error)
+	DoVarArgs(int, ...string) int
+	DoNothing()
+}
@@ -0,0 +1,12 @@
+package fixtures
+
+import "context"
+
+//go:generate counterfeiter --sync-hooks --context-errors . Synchronised
+type Synchronised interface {
+	DoThings(string, uint64) (int, error)
+	Fetch(ctx context.Context, key string) ([]byte, error)
+}
+
+//go:generate counterfeiter --sync-hooks . SynchronisedFunc
+type SynchronisedFunc func(string) string
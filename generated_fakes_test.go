@@ -1,16 +1,22 @@
 package main_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"testing"
 
+	"github.com/maxbrunsfeld/counterfeiter/callorder"
 	"github.com/maxbrunsfeld/counterfeiter/fixtures"
 	"github.com/maxbrunsfeld/counterfeiter/fixtures/fixturesfakes"
 
 	. "github.com/onsi/gomega"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
+	"go.uber.org/mock/gomock"
 )
 
 func TestFakes(t *testing.T) {
@@ -250,6 +256,564 @@ func testFakes(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("fakes generated to return values for specific arguments", func() {
+		var fake *fixturesfakes.FakeMatched
+
+		it.Before(func() {
+			fake = new(fixturesfakes.FakeMatched)
+		})
+
+		it("returns the values of the rule the arguments are equal to", func() {
+			fake.DoThingsReturns(1, nil)
+			fake.DoThingsReturnsWhen("a", 1, 2, nil)
+			fake.DoThingsReturnsWhen("b", 1, 3, errors.New("the-error"))
+
+			n, err := fake.DoThings("b", 1)
+			Expect(n).To(Equal(3))
+			Expect(err).To(MatchError("the-error"))
+
+			n, _ = fake.DoThings("a", 1)
+			Expect(n).To(Equal(2))
+		})
+
+		it("falls back to the default returns when no rule matches", func() {
+			fake.DoThingsReturns(1, nil)
+			fake.DoThingsReturnsWhen("a", 1, 2, nil)
+
+			n, _ := fake.DoThings("a", 2)
+			Expect(n).To(Equal(1))
+		})
+
+		it("can match the arguments with a function", func() {
+			fake.DoThingsReturnsWhenMatches(func(s string, n uint64) bool {
+				return n > 10
+			}, 5, nil)
+
+			Expect(fake.DoThings("a", 11)).To(Equal(5))
+			Expect(fake.DoThings("a", 10)).To(Equal(0))
+		})
+
+		it("prefers the rule added last", func() {
+			fake.DoThingsReturnsWhen("a", 1, 2, nil)
+			fake.DoThingsReturnsWhen("a", 1, 3, nil)
+
+			Expect(fake.DoThings("a", 1)).To(Equal(3))
+		})
+
+		it("prefers stubs and the returns for a specific call", func() {
+			fake.DoThingsReturnsWhen("a", 1, 2, nil)
+			fake.DoThingsReturnsOnCall(0, 4, nil)
+
+			Expect(fake.DoThings("a", 1)).To(Equal(4))
+			Expect(fake.DoThings("a", 1)).To(Equal(2))
+
+			fake.DoThingsStub = func(string, uint64) (int, error) {
+				return 5, nil
+			}
+			Expect(fake.DoThings("a", 1)).To(Equal(5))
+		})
+
+		it("panics in strict mode when no rule matches", func() {
+			fake.DoThingsReturnsWhen("a", 1, 2, nil)
+			fake.DoThingsReturnsWhenStrict()
+
+			Expect(fake.DoThings("a", 1)).To(Equal(2))
+			defer func() {
+				Expect(recover()).To(Equal("FakeMatched.DoThings: no DoThingsReturnsWhen rule matches the arguments"))
+			}()
+			fake.DoThings("b", 1)
+		})
+
+		it("compares var-args as a slice", func() {
+			fake.DoVarArgsReturnsWhen(1, []string{"a", "b"}, 2)
+			fake.DoVarArgsReturnsWhen(1, nil, 3)
+
+			Expect(fake.DoVarArgs(1, "a", "b")).To(Equal(2))
+			Expect(fake.DoVarArgs(1)).To(Equal(3))
+			Expect(fake.DoVarArgs(1, "a")).To(Equal(0))
+		})
+
+		it("is supported by fakes of functions", func() {
+			fake := new(fixturesfakes.FakeMatchedFunc)
+			fake.ReturnsWhen("a", map[string]interface{}{"b": 1}, "c")
+			fake.ReturnsWhenStrict()
+
+			Expect(fake.Spy("a", map[string]interface{}{"b": 1})).To(Equal("c"))
+			Expect(func() {
+				fake.Spy("a", nil)
+			}).To(Panic())
+		})
+	})
+
+	when("fakes generated to return a sequence of values", func() {
+		var fake *fixturesfakes.FakeSequenced
+
+		it.Before(func() {
+			fake = new(fixturesfakes.FakeSequenced)
+		})
+
+		it("returns the values in order and then repeats the last one", func() {
+			fake.DoThingsReturnsSequence().
+				Then(1, nil).
+				Then(2, errors.New("the-error"))
+
+			Expect(fake.DoThings("a", 1)).To(Equal(1))
+			n, err := fake.DoThings("a", 1)
+			Expect(n).To(Equal(2))
+			Expect(err).To(MatchError("the-error"))
+			n, err = fake.DoThings("a", 1)
+			Expect(n).To(Equal(2))
+			Expect(err).To(MatchError("the-error"))
+		})
+
+		it("can start over when the values run out", func() {
+			fake.DoThingsReturnsSequence().Then(1, nil).Then(2, nil).Cycle()
+
+			for _, want := range []int{1, 2, 1, 2} {
+				Expect(fake.DoThings("a", 1)).To(Equal(want))
+			}
+		})
+
+		it("can return zero values when the values run out", func() {
+			fake.DoThingsReturnsSequence().Then(1, errors.New("the-error")).ZeroValues()
+
+			fake.DoThings("a", 1)
+			n, err := fake.DoThings("a", 1)
+			Expect(n).To(Equal(0))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it("can panic when the values run out", func() {
+			fake.DoThingsReturnsSequence().Then(1, nil).Fail()
+
+			Expect(fake.DoThings("a", 1)).To(Equal(1))
+			Expect(panicValue(func() { fake.DoThings("a", 1) })).To(Equal("FakeSequenced.DoThings: the DoThingsReturnsSequence is exhausted"))
+		})
+
+		it("counts the calls made since the sequence was set", func() {
+			fake.DoThings("a", 1)
+			fake.DoThingsReturnsSequence().Then(1, nil).Then(2, nil)
+
+			Expect(fake.DoThings("a", 1)).To(Equal(1))
+		})
+
+		it("prefers stubs and the returns for a specific call, and is replaced by XReturns", func() {
+			fake.DoThingsReturnsSequence().Then(1, nil).Then(2, nil)
+			fake.DoThingsReturnsOnCall(0, 4, nil)
+
+			Expect(fake.DoThings("a", 1)).To(Equal(4))
+			Expect(fake.DoThings("a", 1)).To(Equal(1))
+
+			fake.DoThingsReturns(5, nil)
+			Expect(fake.DoThings("a", 1)).To(Equal(5))
+		})
+
+		it("is supported by fakes of functions", func() {
+			fake := new(fixturesfakes.FakeSequencedFunc)
+			fake.ReturnsSequence().Then("a").Then("b").Fail()
+
+			Expect(fake.Spy("", nil)).To(Equal("a"))
+			Expect(fake.Spy("", nil)).To(Equal("b"))
+			Expect(panicValue(func() { fake.Spy("", nil) })).To(Equal("FakeSequencedFunc: the ReturnsSequence is exhausted"))
+		})
+	})
+
+	when("resetting a fake generated to be reset", func() {
+		var fake *fixturesfakes.FakeReusable
+
+		it.Before(func() {
+			fake = new(fixturesfakes.FakeReusable)
+			fake.DoThingsReturns(1, nil)
+			fake.DoThingsReturnsOnCall(1, 2, nil)
+			fake.DoThingsReturnsWhen("b", 1, 3, nil)
+			fake.DoNothingStub = func() {}
+			fake.DoThings("a", 1)
+			fake.DoNothing()
+		})
+
+		it("clears the calls and the stubs of every method", func() {
+			fake.Reset()
+
+			Expect(fake.DoThingsCallCount()).To(Equal(0))
+			Expect(fake.DoNothingCallCount()).To(Equal(0))
+			Expect(fake.Invocations()).To(BeEmpty())
+			Expect(fake.DoNothingStub).To(BeNil())
+			Expect(fake.DoThings("a", 1)).To(Equal(0))
+			Expect(fake.DoThings("b", 1)).To(Equal(0))
+		})
+
+		it("clears the calls and keeps the stubs", func() {
+			fake.ResetCalls()
+
+			Expect(fake.DoThingsCallCount()).To(Equal(0))
+			Expect(fake.Invocations()).To(BeEmpty())
+			Expect(fake.DoThings("a", 1)).To(Equal(1))
+			Expect(fake.DoThings("a", 1)).To(Equal(2))
+			Expect(fake.DoThings("b", 1)).To(Equal(3))
+		})
+
+		it("clears a single method", func() {
+			fake.DoThingsReset()
+
+			Expect(fake.DoThingsCallCount()).To(Equal(0))
+			Expect(fake.Invocations()["DoThings"]).To(BeEmpty())
+			Expect(fake.DoThings("b", 1)).To(Equal(0))
+			Expect(fake.DoNothingCallCount()).To(Equal(1))
+			Expect(fake.DoNothingStub).NotTo(BeNil())
+		})
+
+		it("starts a sequence over when the calls are cleared", func() {
+			fake.ResetCalls()
+			fake.DoThingsReturnsSequence().Then(4, nil).Then(5, nil)
+			Expect(fake.DoThings("a", 1)).To(Equal(4))
+
+			fake.ResetCalls()
+			Expect(fake.DoThings("a", 1)).To(Equal(4))
+		})
+
+		it("keeps the Reset method of the interface", func() {
+			fake := new(fixturesfakes.FakeResettable)
+			var interfaceVal fixtures.Resettable = fake
+			fake.SizeReturns(1)
+
+			interfaceVal.Reset()
+			Expect(fake.ResetCallCount()).To(Equal(1))
+
+			fake.ResetReset()
+			fake.SizeReset()
+			Expect(fake.ResetCallCount()).To(Equal(0))
+			Expect(fake.Size()).To(Equal(0))
+		})
+
+		it("is supported by fakes of functions", func() {
+			fake := new(fixturesfakes.FakeReusableFunc)
+			fake.Returns("a")
+			fake.Spy("", nil)
+
+			fake.ResetCalls()
+			Expect(fake.CallCount()).To(Equal(0))
+			Expect(fake.Spy("", nil)).To(Equal("a"))
+
+			fake.Reset()
+			Expect(fake.CallCount()).To(Equal(0))
+			Expect(fake.Spy("", nil)).To(BeEmpty())
+		})
+	})
+
+	when("delegating to a real implementation", func() {
+		var fake *fixturesfakes.FakeDelegating
+		var delegate *realSomething
+
+		it.Before(func() {
+			delegate = new(realSomething)
+			fake = fixturesfakes.NewFakeDelegatingWithDelegate(delegate)
+		})
+
+		it("calls the delegate for the methods nothing is stubbed for", func() {
+			Expect(fake.DoThings("abc", 1)).To(Equal(4))
+			fake.DoNothing()
+
+			Expect(delegate.calls).To(Equal([]string{"DoThings", "DoNothing"}))
+			Expect(fake.DoThingsCallCount()).To(Equal(1))
+			arg1, arg2 := fake.DoThingsArgsForCall(0)
+			Expect(arg1).To(Equal("abc"))
+			Expect(arg2).To(Equal(uint64(1)))
+		})
+
+		it("prefers what the methods are stubbed with", func() {
+			fake.DoThingsReturnsOnCall(0, 10, nil)
+			fake.DoThingsReturnsWhen("b", 1, 20, nil)
+			Expect(fake.DoThings("a", 1)).To(Equal(10))
+			Expect(fake.DoThings("b", 1)).To(Equal(20))
+			Expect(fake.DoThings("a", 1)).To(Equal(2))
+
+			fake.DoThingsReturns(30, nil)
+			Expect(fake.DoThings("a", 1)).To(Equal(30))
+
+			fake.DoNothingStub = func() {}
+			fake.DoNothing()
+			Expect(delegate.calls).To(Equal([]string{"DoThings"}))
+		})
+
+		it("calls the delegate again when the fake is reset", func() {
+			fake.DoThingsReturns(30, nil)
+			fake.Reset()
+
+			Expect(fake.DoThings("a", 1)).To(Equal(2))
+		})
+	})
+
+	when("fakes generated to record their calls", func() {
+		var fake *fixturesfakes.FakeRecorded
+
+		it.Before(func() {
+			fake = new(fixturesfakes.FakeRecorded)
+		})
+
+		it("records what each call returned", func() {
+			fake.DoThingsStub = func(arg1 string, arg2 uint64) (int, error) {
+				return len(arg1) + int(arg2), nil
+			}
+			fake.DoThings("abc", 1)
+			fake.DoThings("a", 2)
+
+			Expect(fake.DoThingsResultsForCall(0)).To(Equal(4))
+			result1, err := fake.DoThingsResultsForCall(1)
+			Expect(result1).To(Equal(3))
+			Expect(err).NotTo(HaveOccurred())
+
+			records := fake.DoThingsCallRecords()
+			Expect(records).To(HaveLen(2))
+			Expect(records[1].Arg1).To(Equal("a"))
+			Expect(records[1].Arg2).To(Equal(uint64(2)))
+			Expect(records[1].Result1).To(Equal(3))
+			Expect(records[1].Start).NotTo(BeZero())
+			Expect(records[1].Panic).To(BeNil())
+		})
+
+		it("records the panic of a call and panics again", func() {
+			fake.DoNothingStub = func() {
+				panic("boom")
+			}
+
+			Expect(panicValue(fake.DoNothing)).To(Equal("boom"))
+			records := fake.DoNothingCallRecords()
+			Expect(records).To(HaveLen(1))
+			Expect(records[0].Panic).To(Equal("boom"))
+		})
+
+		it("records how long a call took", func() {
+			fake.DoNothingStub = func() {
+				time.Sleep(10 * time.Millisecond)
+			}
+			fake.DoNothing()
+
+			Expect(fake.DoNothingCallRecords()[0].Duration).To(BeNumerically(">=", 10*time.Millisecond))
+		})
+
+		it("forgets the records when the calls are cleared", func() {
+			fake.DoThings("a", 1)
+			fake.ResetCalls()
+
+			Expect(fake.DoThingsCallRecords()).To(BeEmpty())
+		})
+	})
+
+	when("fakes generated with hooks for concurrent callers", func() {
+		var fake *fixturesfakes.FakeSynchronised
+
+		it.Before(func() {
+			fake = new(fixturesfakes.FakeSynchronised)
+		})
+
+		it("blocks calls until they are released", func() {
+			release := make(chan struct{})
+			fake.DoThingsBlockUntil(release)
+			fake.DoThingsReturns(1, nil)
+
+			done := make(chan int)
+			for i := 0; i < 2; i++ {
+				go func() {
+					n, _ := fake.DoThings("a", 1)
+					done <- n
+				}()
+			}
+			Expect(fake.DoThingsWaitForCalls(2, time.Second)).To(BeTrue())
+			Consistently(done).ShouldNot(Receive())
+
+			release <- struct{}{}
+			Eventually(done).Should(Receive(Equal(1)))
+			Consistently(done).ShouldNot(Receive())
+
+			close(release)
+			Eventually(done).Should(Receive(Equal(1)))
+		})
+
+		it("stops waiting for calls that are not made in time", func() {
+			fake.DoThings("a", 1)
+
+			Expect(fake.DoThingsWaitForCalls(1, 0)).To(BeTrue())
+			Expect(fake.DoThingsWaitForCalls(2, 10*time.Millisecond)).To(BeFalse())
+		})
+
+		it("sends the index of every call to the event channels", func() {
+			events := fake.DoThingsCallEvents(2)
+			fake.DoThings("a", 1)
+			fake.DoThings("b", 2)
+
+			Expect(<-events).To(Equal(0))
+			Expect(<-events).To(Equal(1))
+			arg1, _ := fake.DoThingsArgsForCall(1)
+			Expect(arg1).To(Equal("b"))
+		})
+
+		it("drops the events that do not fit in the channel instead of blocking", func() {
+			events := fake.DoThingsCallEvents(1)
+			unread := fake.DoThingsCallEvents(0)
+			fake.DoThings("a", 1)
+			fake.DoThings("b", 2)
+
+			Expect(fake.DoThingsCallCount()).To(Equal(2))
+			Expect(<-events).To(Equal(0))
+			Consistently(events).ShouldNot(Receive())
+			Consistently(unread).ShouldNot(Receive())
+		})
+
+		it("stops blocking a call when its context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			fake.ReturnContextErrors(true)
+			fake.FetchBlockUntil(make(chan struct{}))
+			done := make(chan error)
+			go func() {
+				_, err := fake.Fetch(ctx, "key")
+				done <- err
+			}()
+
+			Expect(fake.FetchWaitForCalls(1, time.Second)).To(BeTrue())
+			Consistently(done).ShouldNot(Receive())
+			cancel()
+			Eventually(done).Should(Receive(Equal(context.Canceled)))
+		})
+
+		it("is supported by fakes of functions", func() {
+			fake := new(fixturesfakes.FakeSynchronisedFunc)
+			release := make(chan struct{})
+			fake.BlockUntil(release)
+			events := fake.CallEvents(1)
+
+			go fake.Spy("a")
+			Eventually(events).Should(Receive(Equal(0)))
+			Expect(fake.WaitForCalls(1, time.Second)).To(BeTrue())
+			close(release)
+		})
+	})
+
+	when("fakes generated to inject errors", func() {
+		var fake *fixturesfakes.FakeFallible
+		var boom error
+
+		it.Before(func() {
+			fake = new(fixturesfakes.FakeFallible)
+			boom = errors.New("boom")
+			fake.DoThingsReturns(1, nil)
+		})
+
+		it("makes a method fail with an error", func() {
+			fake.DoThingsFailsWith(boom)
+
+			result, err := fake.DoThings("a", 1)
+			Expect(result).To(Equal(0))
+			Expect(err).To(Equal(boom))
+		})
+
+		it("makes a single call fail with an error", func() {
+			fake.DoThingsFailsOnCall(1, boom)
+
+			Expect(fake.DoThings("a", 1)).To(Equal(1))
+			_, err := fake.DoThings("a", 1)
+			Expect(err).To(Equal(boom))
+			Expect(fake.DoThings("a", 1)).To(Equal(1))
+		})
+
+		it("makes every method that returns an error fail", func() {
+			fake.FailAll(boom)
+
+			_, err := fake.DoThings("a", 1)
+			Expect(err).To(Equal(boom))
+			Expect(fake.DoThingsCallCount()).To(Equal(1))
+
+			fake.FailAll(nil)
+			Expect(fake.DoThings("a", 1)).To(Equal(1))
+		})
+
+		it("leaves the calls stubbed with XStub or XReturnsOnCall alone", func() {
+			fake.DoThingsReturnsOnCall(0, 3, nil)
+			fake.FailAll(boom)
+
+			Expect(fake.DoThings("a", 1)).To(Equal(3))
+			_, err := fake.DoThings("a", 1)
+			Expect(err).To(Equal(boom))
+
+			fake.DoThingsStub = func(string, uint64) (int, error) {
+				return 2, nil
+			}
+			Expect(fake.DoThings("a", 1)).To(Equal(2))
+		})
+
+		it("makes the methods fail at random, the same way for the same seed", func() {
+			failures := func() []bool {
+				fake.FailRandomly(42, 0.5, boom)
+				var result []bool
+				for i := 0; i < 20; i++ {
+					_, err := fake.DoThings("a", 1)
+					result = append(result, err != nil)
+				}
+				return result
+			}
+			first := failures()
+			Expect(first).To(ContainElement(true))
+			Expect(first).To(ContainElement(false))
+			Expect(failures()).To(Equal(first))
+		})
+
+		it("stops failing when the fake is reset", func() {
+			fake.FailAll(boom)
+			fake.Reset()
+
+			_, err := fake.DoThings("a", 1)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	when("methods take a context", func() {
+		var fake *fixturesfakes.FakeContextAware
+		var ctx context.Context
+		var cancel context.CancelFunc
+
+		it.Before(func() {
+			fake = new(fixturesfakes.FakeContextAware)
+			fake.FetchReturns([]byte("data"), nil)
+			ctx, cancel = context.WithCancel(context.Background())
+		})
+
+		it.After(func() {
+			cancel()
+		})
+
+		it("ignores the context by default", func() {
+			cancel()
+
+			data, err := fake.Fetch(ctx, "key")
+			Expect(data).To(Equal([]byte("data")))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		when("the fake returns the errors of the context", func() {
+			it.Before(func() {
+				fake.ReturnContextErrors(true)
+			})
+
+			it("returns the error of a context that is already done", func() {
+				Expect(fake.Fetch(ctx, "key")).To(Equal([]byte("data")))
+
+				cancel()
+				data, err := fake.Fetch(ctx, "key")
+				Expect(data).To(BeNil())
+				Expect(err).To(Equal(context.Canceled))
+				Expect(fake.Close(ctx)).To(Equal(context.Canceled))
+				Expect(fake.FetchCallCount()).To(Equal(2))
+			})
+
+			it("leaves the methods without an error result as they are", func() {
+				fake.NameReturns("name")
+				cancel()
+
+				Expect(fake.Name()).To(Equal("name"))
+			})
+		})
+	})
+
 	when("interfaces with var-args methods", func() {
 		var fake *fixturesfakes.FakeHasVarArgs
 
@@ -280,8 +844,522 @@ func testFakes(t *testing.T, when spec.G, it spec.S) {
 			Expect(val).To(Equal(11))
 		})
 	})
+
+	when("fakes generated with the parameter names from the source", func() {
+		var fake *fixturesfakes.FakeNamedParams
+
+		it.Before(func() {
+			fake = new(fixturesfakes.FakeNamedParams)
+		})
+
+		it("implements the interface", func() {
+			var interfaceVal fixtures.NamedParams = fake
+			Expect(interfaceVal).NotTo(BeNil())
+		})
+
+		it("records the arguments and returns the stubbed results", func() {
+			fake.DoThingsReturns(3, errors.New("the-error"))
+
+			n, err := fake.DoThings("stuff", 5)
+			Expect(n).To(Equal(3))
+			Expect(err).To(MatchError("the-error"))
+
+			name, count := fake.DoThingsArgsForCall(0)
+			Expect(name).To(Equal("stuff"))
+			Expect(count).To(Equal(uint64(5)))
+		})
+
+		it("records a slice argument as a copy", func() {
+			data := []byte{1}
+
+			fake.Unnamed("a", 1, data, "url", "b", "c")
+
+			data[0] = 2
+			_, _, recorded, _, items := fake.UnnamedArgsForCall(0)
+			Expect(recorded).To(ConsistOf(byte(1)))
+			Expect(items).To(Equal([]string{"b", "c"}))
+		})
+	})
+
+	when("fakes generated to deep copy their arguments", func() {
+		var fake *fixturesfakes.FakeDeepCopied
+
+		it.Before(func() {
+			fake = new(fixturesfakes.FakeDeepCopied)
+		})
+
+		it("implements the interface", func() {
+			var interfaceVal fixtures.DeepCopied = fake
+			Expect(interfaceVal).NotTo(BeNil())
+		})
+
+		it("records pointers to structs as a copy", func() {
+			settings := &fixtures.Settings{Name: "a", Tags: []string{"x"}, Limits: map[string]int{"n": 1}}
+
+			fake.Save("key", settings)
+
+			settings.Name = "b"
+			settings.Tags[0] = "y"
+			settings.Limits["n"] = 2
+			_, recorded := fake.SaveArgsForCall(0)
+			Expect(recorded).To(Equal(&fixtures.Settings{Name: "a", Tags: []string{"x"}, Limits: map[string]int{"n": 1}}))
+		})
+
+		it("records maps, nested slices and arrays as a copy", func() {
+			values := map[string][]string{"k": {"v"}}
+			grid := [2][]int{{1}, {2}}
+
+			fake.Merge(values, grid)
+
+			values["k"][0] = "w"
+			values["l"] = nil
+			grid[0][0] = 3
+			recordedValues, recordedGrid := fake.MergeArgsForCall(0)
+			Expect(recordedValues).To(Equal(map[string][]string{"k": {"v"}}))
+			Expect(recordedGrid).To(Equal([2][]int{{1}, {2}}))
+		})
+
+		it("records the values of slices of pointers and variadic arguments as a copy, and interfaces as they are", func() {
+			settings := []*fixtures.Settings{{Name: "a"}, nil}
+			keys := []string{"k"}
+			writer := new(bytes.Buffer)
+
+			fake.Batch(settings, writer, keys...)
+
+			settings[0].Name = "b"
+			keys[0] = "l"
+			recorded, recordedWriter, recordedKeys := fake.BatchArgsForCall(0)
+			Expect(recorded).To(Equal([]*fixtures.Settings{{Name: "a"}, nil}))
+			Expect(recordedWriter).To(BeIdenticalTo(writer))
+			Expect(recordedKeys).To(Equal([]string{"k"}))
+			Expect(fake.Invocations()["Batch"][0][0]).To(Equal([]*fixtures.Settings{{Name: "a"}, nil}))
+		})
+
+		it("keeps shared pointers shared in the copy", func() {
+			shared := &fixtures.Settings{Name: "a"}
+
+			fake.Batch([]*fixtures.Settings{shared, shared}, nil)
+
+			recorded, _, _ := fake.BatchArgsForCall(0)
+			Expect(recorded[0]).NotTo(BeIdenticalTo(shared))
+			Expect(recorded[0]).To(BeIdenticalTo(recorded[1]))
+		})
+	})
+
+	when("fakes generated to be strict", func() {
+		var fake *fixturesfakes.FakeStrict
+
+		it.Before(func() {
+			fake = new(fixturesfakes.FakeStrict)
+		})
+
+		it("implements the interface", func() {
+			var interfaceVal fixtures.Strict = fake
+			Expect(interfaceVal).NotTo(BeNil())
+		})
+
+		it("panics when a method is called while nothing is stubbed for it", func() {
+			Expect(panicValue(func() { fake.Lookup("key") })).To(Equal("FakeStrict.Lookup was called with [key], but nothing is stubbed for it"))
+			Expect(panicValue(func() { fake.Count() })).To(Equal("FakeStrict.Count was called with [], but nothing is stubbed for it"))
+			Expect(panicValue(func() { fake.Forget("key") })).To(Equal("FakeStrict.Forget was called with [key], but nothing is stubbed for it"))
+			Expect(fake.LookupCallCount()).To(Equal(1))
+			Expect(fake.ForgetCallCount()).To(Equal(1))
+		})
+
+		it("does not fail methods without results that are stubbed or allowed", func() {
+			var forgotten []string
+			fake.ForgetCalls(func(key string) { forgotten = append(forgotten, key) })
+			fake.Forget("key")
+			Expect(forgotten).To(Equal([]string{"key"}))
+
+			fake.ForgetCalls(nil)
+			fake.AllowUnstubbed("Forget")
+			fake.Forget("other")
+			Expect(fake.ForgetCallCount()).To(Equal(2))
+		})
+
+		it("does not fail methods that are stubbed", func() {
+			fake.LookupReturns("value", nil)
+			fake.CountStub = func() int { return 2 }
+
+			value, _ := fake.Lookup("key")
+			Expect(value).To(Equal("value"))
+			Expect(fake.Count()).To(Equal(2))
+		})
+
+		it("fails calls after the ones stubbed with XReturnsOnCall", func() {
+			fake.LookupReturnsOnCall(0, "first", nil)
+
+			value, _ := fake.Lookup("key")
+			Expect(value).To(Equal("first"))
+			Expect(panicValue(func() { fake.Lookup("key") })).NotTo(BeNil())
+		})
+
+		it("does not fail methods stubbed with a sequence", func() {
+			fake.CountReturnsSequence().Then(1).ZeroValues()
+
+			Expect(fake.Count()).To(Equal(1))
+			Expect(fake.Count()).To(Equal(0))
+		})
+
+		it("fails calls that no XReturnsWhen rule matches", func() {
+			fake.LookupReturnsWhen("key", "value", nil)
+
+			value, _ := fake.Lookup("key")
+			Expect(value).To(Equal("value"))
+			Expect(panicValue(func() { fake.Lookup("other") })).NotTo(BeNil())
+		})
+
+		it("returns zero values for the methods that are allowed to be unstubbed", func() {
+			fake.AllowUnstubbed("Lookup")
+
+			value, err := fake.Lookup("key")
+			Expect(value).To(BeEmpty())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(panicValue(func() { fake.Count() })).NotTo(BeNil())
+		})
+
+		it("fails the test it is given instead of panicking", func() {
+			tb := new(recordingTB)
+			fake.SetTB(tb)
+
+			fake.Lookup("key")
+			Expect(tb.helper).To(BeTrue())
+			Expect(tb.failures).To(Equal([]string{"FakeStrict.Lookup was called with [key], but nothing is stubbed for it"}))
+		})
+
+		it("makes function fakes strict too", func() {
+			fake := new(fixturesfakes.FakeStrictFunc)
+			Expect(panicValue(func() { fake.Spy("name") })).To(Equal("FakeStrictFunc.StrictFunc was called with [name], but nothing is stubbed for it"))
+
+			fake.Returns(true)
+			Expect(fake.Spy("name")).To(BeTrue())
+		})
+
+		it("makes function fakes without results strict too", func() {
+			fake := new(fixturesfakes.FakeStrictVoidFunc)
+			Expect(panicValue(func() { fake.Spy("name") })).To(Equal("FakeStrictVoidFunc.StrictVoidFunc was called with [name], but nothing is stubbed for it"))
+
+			fake.Calls(func(string) {})
+			fake.Spy("name")
+			Expect(fake.CallCount()).To(Equal(2))
+		})
+	})
+
+	when("fakes generated with expectations", func() {
+		var (
+			fake *fixturesfakes.FakeExpected
+			tb   *recordingTB
+		)
+
+		it.Before(func() {
+			fake = new(fixturesfakes.FakeExpected)
+			tb = new(recordingTB)
+		})
+
+		it("implements the interface", func() {
+			var interfaceVal fixtures.Expected = fake
+			Expect(interfaceVal).NotTo(BeNil())
+		})
+
+		it("returns the results of the expectation a call matches", func() {
+			fake.DoThingsReturns(1, nil)
+			fake.ExpectDoThings("stuff", 5).Times(2).Return(3, nil)
+			fake.ExpectDoThings("other", 1).Return(4, errors.New("the-error"))
+
+			n, _ := fake.DoThings("stuff", 5)
+			Expect(n).To(Equal(3))
+			n, err := fake.DoThings("other", 1)
+			Expect(n).To(Equal(4))
+			Expect(err).To(MatchError("the-error"))
+			n, _ = fake.DoThings("stuff", 5)
+			Expect(n).To(Equal(3))
+			n, _ = fake.DoThings("stuff", 5)
+			Expect(n).To(Equal(1))
+		})
+
+		it("passes verification when every expected call was made", func() {
+			fake.ExpectDoThings("stuff", 5).Times(2)
+			fake.ExpectClose()
+
+			fake.DoThings("stuff", 5)
+			fake.Close()
+			fake.DoThings("stuff", 5)
+
+			fake.Verify(tb)
+			Expect(tb.helper).To(BeTrue())
+			Expect(tb.failures).To(BeEmpty())
+		})
+
+		it("reports missing calls", func() {
+			fake.ExpectDoThings("stuff", 5).Times(2)
+			fake.ExpectClose()
+
+			fake.DoThings("stuff", 5)
+
+			fake.Verify(tb)
+			Expect(tb.failures).To(Equal([]string{
+				"FakeExpected.Close with []: missing calls, want 1, got 0",
+				"FakeExpected.DoThings with [stuff 5]: missing calls, want 2, got 1",
+			}))
+		})
+
+		it("reports extra calls", func() {
+			fake.ExpectReady().Return(true)
+
+			fake.Ready()
+			fake.Ready()
+
+			fake.Verify(tb)
+			Expect(tb.failures).To(Equal([]string{
+				"FakeExpected.Ready with []: extra calls, want 1, got 2",
+			}))
+		})
+
+		it("reports unexpected calls with how they differ from the closest expectation", func() {
+			fake.ExpectDoThings("stuff", 5)
+			fake.ExpectDoThings("other", 6)
+
+			fake.DoThings("stuff", 5)
+			fake.DoThings("stuff", 6)
+
+			fake.Verify(tb)
+			Expect(tb.failures).To(Equal([]string{
+				"FakeExpected.DoThings with [stuff 6]: unexpected call\n\tclosest expectation: [stuff 5]\n\targument 2: want 5, got 6",
+				"FakeExpected.DoThings with [other 6]: missing calls, want 1, got 0",
+			}))
+		})
+
+		it("reports the calls to methods without expectations as unexpected", func() {
+			fake.ExpectDoThings("stuff", 5)
+
+			fake.DoThings("stuff", 5)
+			fake.Ready()
+			fake.Close()
+
+			fake.Verify(tb)
+			Expect(tb.failures).To(Equal([]string{
+				"FakeExpected.Close with []: unexpected call\n\tno calls to Close are expected",
+				"FakeExpected.Ready with []: unexpected call\n\tno calls to Ready are expected",
+			}))
+		})
+
+		when("the fake is also strict", func() {
+			var strict *fixturesfakes.FakeStrictlyExpected
+
+			it.Before(func() {
+				strict = new(fixturesfakes.FakeStrictlyExpected)
+				strict.SetTB(tb)
+			})
+
+			it("treats the calls that match an expectation as stubbed", func() {
+				strict.ExpectDoThings("stuff", 5).Return(3, nil)
+				strict.ExpectClose().Times(2)
+
+				n, _ := strict.DoThings("stuff", 5)
+				Expect(n).To(Equal(3))
+				strict.Close()
+				strict.Close()
+				Expect(tb.failures).To(BeEmpty())
+			})
+
+			it("fails the calls to methods without results that match no expectation", func() {
+				strict.ExpectClose()
+
+				strict.Close()
+				Expect(tb.failures).To(BeEmpty())
+
+				strict.Close()
+				Expect(tb.failures).To(Equal([]string{
+					"FakeStrictlyExpected.Close was called with [], but nothing is stubbed for it",
+				}))
+			})
+		})
+
+		it("verifies the expectations when the test is cleaned up", func() {
+			fake.ExpectClose()
+
+			fake.VerifyOnCleanup(tb)
+			Expect(tb.failures).To(BeEmpty())
+
+			tb.cleanup()
+			Expect(tb.failures).To(Equal([]string{"FakeExpected.Close with []: missing calls, want 1, got 0"}))
+		})
+	})
+
+	when("doubles generated in the style of testify", func() {
+		var fake *fixturesfakes.TestifyStyled
+
+		it.Before(func() {
+			fake = fixturesfakes.NewTestifyStyled(t)
+		})
+
+		it("implements the interface", func() {
+			var interfaceVal fixtures.Styled = fake
+			Expect(interfaceVal).NotTo(BeNil())
+		})
+
+		it("returns what the mock is told to for the calls it expects", func() {
+			fake.On("DoThings", "stuff", uint64(5)).Return(3, errors.New("the-error"))
+			fake.On("DoVarArgs", 1, []string{"a", "b"}).Return(2)
+			fake.On("DoNothing").Once()
+
+			n, err := fake.DoThings("stuff", 5)
+			Expect(n).To(Equal(3))
+			Expect(err).To(MatchError("the-error"))
+			Expect(fake.DoVarArgs(1, "a", "b")).To(Equal(2))
+			fake.DoNothing()
+			Expect(fake.AssertExpectations(t)).To(BeTrue())
+		})
+	})
+
+	when("doubles generated in the style of gomock", func() {
+		var fake *fixturesfakes.GomockStyled
+
+		it.Before(func() {
+			fake = fixturesfakes.NewGomockStyled(gomock.NewController(t))
+		})
+
+		it("implements the interface", func() {
+			var interfaceVal fixtures.Styled = fake
+			Expect(interfaceVal).NotTo(BeNil())
+		})
+
+		it("returns what the recorder is told to for the calls it expects", func() {
+			fake.EXPECT().DoThings("stuff", gomock.Any()).Return(3, errors.New("the-error"))
+			fake.EXPECT().DoVarArgs(1, "a", "b").Return(2)
+			fake.EXPECT().DoNothing().Times(1)
+
+			n, err := fake.DoThings("stuff", 5)
+			Expect(n).To(Equal(3))
+			Expect(err).To(MatchError("the-error"))
+			Expect(fake.DoVarArgs(1, "a", "b")).To(Equal(2))
+			fake.DoNothing()
+		})
+	})
+
+	when("recording the order of calls across fakes", func() {
+		var (
+			first  *fixturesfakes.FakeOrdered
+			second *fixturesfakes.FakeOrdered
+			spy    *fixturesfakes.FakeOrderedFunc
+			seq    *callorder.Sequencer
+		)
+
+		it.Before(func() {
+			first = new(fixturesfakes.FakeOrdered)
+			second = new(fixturesfakes.FakeOrdered)
+			spy = new(fixturesfakes.FakeOrderedFunc)
+			seq = callorder.NewSequencer(first, second, spy)
+		})
+
+		it("records every call in a single sequence", func() {
+			second.DoNothing()
+			first.DoNothing()
+			spy.Spy("name", nil)
+			first.DoThings("a", 1)
+
+			calls := seq.Calls()
+			Expect(calls).To(HaveLen(4))
+			Expect(calls[0].Fake).To(BeIdenticalTo(second))
+			Expect(calls[1].Fake).To(BeIdenticalTo(first))
+			Expect(calls[2].Method).To(Equal("OrderedFunc"))
+			Expect(calls[2].Args).To(Equal([]interface{}{"name", map[string]interface{}(nil)}))
+			Expect(calls[3].Seq).To(Equal(uint64(4)))
+
+			Expect(seq.CheckOrder(
+				callorder.Called(second, "DoNothing"),
+				callorder.Called(first, "DoThings"),
+			)).To(Succeed())
+			Expect(seq.CheckOrder(
+				callorder.Called(first, "DoThings"),
+				callorder.Called(second, "DoNothing"),
+			)).NotTo(Succeed())
+		})
+
+		it("still records the calls for each fake", func() {
+			first.DoNothing()
+			Expect(first.Invocations()["DoNothing"]).To(HaveLen(1))
+		})
+
+		it("lets the recorder read the fake back", func() {
+			counts := []int{}
+			first.SetCallRecorder(recorderFunc(func(interface{}, string, []interface{}) {
+				counts = append(counts, first.DoNothingCallCount(), len(first.Invocations()["DoNothing"]))
+			}))
+
+			first.DoNothing()
+			Expect(counts).To(Equal([]int{1, 1}))
+		})
+	})
 }
 
 type InvocationRecorder interface {
 	Invocations() map[string][][]interface{}
 }
+
+type recorderFunc func(fake interface{}, method string, args []interface{})
+
+func (f recorderFunc) RecordCall(fake interface{}, method string, args []interface{}) {
+	f(fake, method, args)
+}
+
+// panicValue returns the value f panics with, or nil.
+func panicValue(f func()) (value interface{}) {
+	defer func() {
+		value = recover()
+	}()
+	f()
+	return nil
+}
+
+// recordingTB records the failures reported by a fake.
+type recordingTB struct {
+	helper   bool
+	failures []string
+	cleanups []func()
+}
+
+func (tb *recordingTB) Helper() {
+	tb.helper = true
+}
+
+func (tb *recordingTB) Fatalf(format string, args ...interface{}) {
+	tb.failures = append(tb.failures, fmt.Sprintf(format, args...))
+}
+
+func (tb *recordingTB) Errorf(format string, args ...interface{}) {
+	tb.failures = append(tb.failures, fmt.Sprintf(format, args...))
+}
+
+func (tb *recordingTB) Cleanup(f func()) {
+	tb.cleanups = append(tb.cleanups, f)
+}
+
+func (tb *recordingTB) cleanup() {
+	for i := len(tb.cleanups) - 1; i >= 0; i-- {
+		tb.cleanups[i]()
+	}
+}
+
+// realSomething is a working fixtures.Something for fakes to delegate to.
+type realSomething struct {
+	calls []string
+}
+
+func (r *realSomething) DoThings(s string, n uint64) (int, error) {
+	r.calls = append(r.calls, "DoThings")
+	return len(s) + int(n), nil
+}
+
+func (r *realSomething) DoNothing() {
+	r.calls = append(r.calls, "DoNothing")
+}
+
+func (r *realSomething) DoASlice([]byte) {
+	r.calls = append(r.calls, "DoASlice")
+}
+
+func (r *realSomething) DoAnArray([4]byte) {
+	r.calls = append(r.calls, "DoAnArray")
+}
@@ -0,0 +1,99 @@
+//go:build go1.18
+// +build go1.18
+
+package main_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/maxbrunsfeld/counterfeiter/fixtures"
+	"github.com/maxbrunsfeld/counterfeiter/fixtures/fixturesfakes"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestGenericFakes(t *testing.T) {
+	spec.Run(t, "GenericFakes", testGenericFakes, spec.Report(report.Terminal{}))
+}
+
+func testGenericFakes(t *testing.T, when spec.G, it spec.S) {
+	it.Before(func() {
+		RegisterTestingT(t)
+	})
+
+	when("faking a generic interface", func() {
+		var fake *fixturesfakes.FakeRepository[fixtures.User]
+
+		it.Before(func() {
+			fake = new(fixturesfakes.FakeRepository[fixtures.User])
+		})
+
+		it("implements every instance of the interface", func() {
+			var interfaceVal fixtures.Repository[fixtures.User] = fake
+			Expect(interfaceVal).NotTo(BeNil())
+
+			var otherVal fixtures.Repository[int] = new(fixturesfakes.FakeRepository[int])
+			Expect(otherVal).NotTo(BeNil())
+		})
+
+		it("can have its return values configured", func() {
+			fake.GetReturns(fixtures.User{Name: "ada"}, nil)
+
+			user, err := fake.Get("1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user).To(Equal(fixtures.User{Name: "ada"}))
+			Expect(fake.GetArgsForCall(0)).To(Equal("1"))
+		})
+
+		it("records var-args of the type parameter", func() {
+			fake.Save(fixtures.User{Name: "ada"}, fixtures.User{Name: "bob"})
+
+			Expect(fake.SaveArgsForCall(0)).To(HaveLen(2))
+		})
+	})
+
+	when("faking an instance of a generic interface", func() {
+		it("implements that instance", func() {
+			fake := new(fixturesfakes.FakeRepositoryUser)
+			var interfaceVal fixtures.Repository[fixtures.User] = fake
+			Expect(interfaceVal).NotTo(BeNil())
+
+			fake.ListReturns([]fixtures.User{{Name: "ada"}})
+			Expect(fake.List(nil)).To(Equal([]fixtures.User{{Name: "ada"}}))
+		})
+
+		it("can use type arguments from other packages", func() {
+			fake := new(fixturesfakes.FakeCacheStringBuffer)
+			var interfaceVal fixtures.Cache[string, *bytes.Buffer] = fake
+			Expect(interfaceVal).NotTo(BeNil())
+		})
+	})
+
+	when("faking a generic function type", func() {
+		it("records its calls and returns the stubbed results", func() {
+			fake := new(fixturesfakes.FakeMapper[string, int])
+			var mapper fixtures.Mapper[string, int] = fake.Spy
+			fake.ReturnsOnCall(1, 0, errors.New("the-error"))
+			fake.Returns(5, nil)
+
+			result, err := mapper("five")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(5))
+
+			_, err = mapper("six")
+			Expect(err).To(MatchError("the-error"))
+			Expect(fake.CallCount()).To(Equal(2))
+			Expect(fake.ArgsForCall(1)).To(Equal("six"))
+		})
+
+		it("fakes an instance of the function type", func() {
+			fake := new(fixturesfakes.FakeMapperStringInt)
+			var mapper fixtures.Mapper[string, int] = fake.Spy
+			Expect(mapper).NotTo(BeNil())
+		})
+	})
+}
@@ -0,0 +1,50 @@
+package generator
+
+import "go/types"
+
+// WithContextErrors adds ReturnContextErrors to the fake of an interface with
+// methods that take a context.Context, which makes them return ctx.Err() for
+// a context that is done.
+func WithContextErrors() Option {
+	return func(f *Fake) {
+		f.ContextErrors = true
+	}
+}
+
+// takesContext is true if the first parameter of sig is a context.Context and
+// its last result is an error, so that its fake can return ctx.Err() for a
+// context that is done.
+func takesContext(sig *types.Signature) bool {
+	if sig.Params().Len() == 0 {
+		return false
+	}
+	if sig.Variadic() && sig.Params().Len() == 1 {
+		return false
+	}
+	named, ok := sig.Params().At(0).Type().(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "context" || named.Obj().Name() != "Context" {
+		return false
+	}
+	return returnsError(sig)
+}
+
+// ContextArg is the name of the context.Context parameter of a method that
+// takes a context.
+func (m Method) ContextArg() string {
+	return unexport(m.Params[0].Name)
+}
+
+// TakesContext is true if the fake of an interface has methods that take a
+// context.Context, which can be made to return ctx.Err() with
+// ReturnContextErrors.
+func (f *Fake) TakesContext() bool {
+	if !f.ContextErrors || f.Mode != InterfaceOrFunction || !f.IsInterface() || f.DeclaresMethod("ReturnContextErrors") {
+		return false
+	}
+	for i := range f.Methods {
+		if f.Methods[i].TakesContext {
+			return true
+		}
+	}
+	return false
+}
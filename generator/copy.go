@@ -0,0 +1,120 @@
+package generator
+
+import "go/types"
+
+// WithDeepCopyArgs makes the fake record a deep copy of every argument that
+// holds maps, slices, arrays or pointers, so that changes the caller makes to
+// them after the call do not change what XArgsForCall returns.
+func WithDeepCopyArgs() Option {
+	return func(f *Fake) {
+		f.DeepCopyArgs = true
+	}
+}
+
+// DeepCopiesArgs is true if the fake deep copies any of the arguments it
+// records, for which it needs the deepCopy helper.
+func (f *Fake) DeepCopiesArgs() bool {
+	if f.Mode == Package || f.Mode == Struct {
+		return false
+	}
+	if f.IsFunction() {
+		return len(f.Function.Params.DeepCopies()) > 0
+	}
+	for i := range f.Methods {
+		if len(f.Methods[i].Params.DeepCopies()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// needsDeepCopy is true if a value of type t may share memory with the caller
+// through a map, slice or pointer. Interfaces, functions and channels are
+// recorded as they are, since copying them would lose their identity. A type
+// parameter may be anything, so it is copied.
+func needsDeepCopy(t types.Type, seen map[types.Type]bool) bool {
+	if isTypeParam(t) {
+		return true
+	}
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch u := t.Underlying().(type) {
+	case *types.Pointer, *types.Map, *types.Slice:
+		return true
+	case *types.Array:
+		return needsDeepCopy(u.Elem(), seen)
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if needsDeepCopy(u.Field(i).Type(), seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deepCopyTemplate defines the deepCopy helper of the interface and function
+// templates. Fields that are not exported are copied as they are, since
+// reflection cannot set them.
+const deepCopyTemplate string = `{{define "deepCopy" -}}
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) deepCopy(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	type visit struct {
+		ptr uintptr
+		typ reflect.Type
+	}
+	copies := map[visit]reflect.Value{}
+	var copyValue func(v reflect.Value) reflect.Value
+	copyValue = func(v reflect.Value) reflect.Value {
+		result := reflect.New(v.Type()).Elem()
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Map:
+			if v.IsNil() {
+				return v
+			}
+			key := visit{v.Pointer(), v.Type()}
+			if copied, ok := copies[key]; ok {
+				return copied
+			}
+			if v.Kind() == reflect.Ptr {
+				result = reflect.New(v.Type().Elem())
+				copies[key] = result
+				result.Elem().Set(copyValue(v.Elem()))
+				return result
+			}
+			result = reflect.MakeMapWithSize(v.Type(), v.Len())
+			copies[key] = result
+			for _, k := range v.MapKeys() {
+				result.SetMapIndex(k, copyValue(v.MapIndex(k)))
+			}
+		case reflect.Slice:
+			if v.IsNil() {
+				return v
+			}
+			result = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+			for i := 0; i < v.Len(); i++ {
+				result.Index(i).Set(copyValue(v.Index(i)))
+			}
+		case reflect.Array:
+			for i := 0; i < v.Len(); i++ {
+				result.Index(i).Set(copyValue(v.Index(i)))
+			}
+		case reflect.Struct:
+			result.Set(v)
+			for i := 0; i < v.NumField(); i++ {
+				if v.Type().Field(i).PkgPath == "" {
+					result.Field(i).Set(copyValue(v.Field(i)))
+				}
+			}
+		default:
+			result.Set(v)
+		}
+		return result
+	}
+	return copyValue(reflect.ValueOf(value)).Interface()
+}
+{{- end}}`
@@ -0,0 +1,118 @@
+package generator
+
+// WithExpectations adds an expectation layer to the fake of an interface: the
+// calls it expects are declared up front with ExpectX and checked against the
+// calls it recorded with Verify.
+func WithExpectations() Option {
+	return func(f *Fake) {
+		f.Expectations = true
+	}
+}
+
+// HasExpectations is true if the fake has the expectation layer.
+func (f *Fake) HasExpectations() bool {
+	return f.Expectations && f.Mode == InterfaceOrFunction && f.IsInterface()
+}
+
+// verifyTemplate defines the methods of a fake with expectations that check
+// the recorded invocations against the expected calls.
+const verifyTemplate string = `{{define "verify" -}}
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) Verify(t interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}) {
+	t.Helper()
+	invocations := fake.Invocations()
+	{{- range .Methods}}
+	{
+		fake.{{UnExport .Name}}Mutex.RLock()
+		var expected [][]interface{}
+		var times []int
+		for _, expectation := range fake.{{UnExport .Name}}Expectations {
+			expected = append(expected, expectation.args)
+			times = append(times, expectation.times)
+		}
+		fake.{{UnExport .Name}}Mutex.RUnlock()
+		fake.verifyCalls(t, "{{.Name}}", expected, times, invocations["{{.Name}}"])
+	}
+	{{- end}}
+}
+
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) VerifyOnCleanup(t interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Cleanup(func())
+}) {
+	t.Cleanup(func() {
+		fake.Verify(t)
+	})
+}
+
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) verifyCalls(t interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}, method string, expected [][]interface{}, times []int, calls [][]interface{}) {
+	t.Helper()
+	if len(expected) == 0 {
+		for _, call := range calls {
+			t.Errorf("{{.Name}}.%s with %v: unexpected call\n\tno calls to %s are expected", method, call, method)
+		}
+		return
+	}
+	counts := make([]int, len(expected))
+	for _, call := range calls {
+		matched, exhausted := -1, -1
+		for i := range expected {
+			if !reflect.DeepEqual(expected[i], call) {
+				continue
+			}
+			if counts[i] < times[i] {
+				matched = i
+				break
+			}
+			if exhausted < 0 {
+				exhausted = i
+			}
+		}
+		switch {
+		case matched >= 0:
+			counts[matched]++
+		case exhausted >= 0:
+			counts[exhausted]++
+		default:
+			closest, differences := 0, len(call)+1
+			for i := range expected {
+				n := 0
+				for j := range call {
+					if !reflect.DeepEqual(expected[i][j], call[j]) {
+						n++
+					}
+				}
+				if n < differences {
+					closest, differences = i, n
+				}
+			}
+			message := fmt.Sprintf("{{.Name}}.%s with %v: unexpected call\n\tclosest expectation: %v", method, call, expected[closest])
+			for j := range call {
+				if reflect.DeepEqual(expected[closest][j], call[j]) {
+					continue
+				}
+				want, got := fmt.Sprintf("%v", expected[closest][j]), fmt.Sprintf("%v", call[j])
+				if want == got {
+					want, got = fmt.Sprintf("%#v", expected[closest][j]), fmt.Sprintf("%#v", call[j])
+				}
+				message += fmt.Sprintf("\n\targument %d: want %s, got %s", j+1, want, got)
+			}
+			t.Errorf("%s", message)
+		}
+	}
+	for i := range expected {
+		switch {
+		case counts[i] < times[i]:
+			t.Errorf("{{.Name}}.%s with %v: missing calls, want %d, got %d", method, expected[i], times[i], counts[i])
+		case counts[i] > times[i]:
+			t.Errorf("{{.Name}}.%s with %v: extra calls, want %d, got %d", method, expected[i], times[i], counts[i])
+		}
+	}
+}
+{{- end}}`
@@ -0,0 +1,47 @@
+package generator
+
+import "go/types"
+
+// returnsError is true if the last result of sig is an error.
+func returnsError(sig *types.Signature) bool {
+	if sig.Results().Len() == 0 {
+		return false
+	}
+	return types.Identical(sig.Results().At(sig.Results().Len()-1).Type(), types.Universe.Lookup("error").Type())
+}
+
+// ErrorResult is the name of the error result of a method that returns an
+// error.
+func (m Method) ErrorResult() string {
+	return unexport(m.Returns[len(m.Returns)-1].Name)
+}
+
+// WithInjectErrors adds XFailsWith and XFailsOnCall to the fake of an
+// interface for its methods that return an error, and FailAll and
+// FailRandomly to make all of them fail.
+func WithInjectErrors() Option {
+	return func(f *Fake) {
+		f.InjectErrors = true
+	}
+}
+
+// InjectsErrors is true if the fake of an interface can make its methods
+// that return an error fail.
+func (f *Fake) InjectsErrors() bool {
+	return f.InjectErrors && f.Mode == InterfaceOrFunction && f.IsInterface()
+}
+
+// FailsAll is true if the fake that injects errors has methods that return
+// an error, which FailAll and FailRandomly make fail. They are left out when
+// the interface has methods of the same names.
+func (f *Fake) FailsAll() bool {
+	if !f.InjectsErrors() || f.DeclaresMethod("FailAll") || f.DeclaresMethod("FailRandomly") {
+		return false
+	}
+	for i := range f.Methods {
+		if f.Methods[i].ReturnsError {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package generator
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"go/types"
 	"log"
@@ -17,10 +18,13 @@ import (
 // FakeMode indicates the type of Fake to generate.
 type FakeMode int
 
-// FakeMode can be Interface, Function, or Package.
+// FakeMode can be Interface, Function, Package, or Struct.
 const (
 	InterfaceOrFunction FakeMode = iota
 	Package
+	// Struct extracts an interface from the exported methods of a struct
+	// type, instead of generating a fake.
+	Struct
 )
 
 // Fake is used to generate a Fake implementation of an interface.
@@ -28,16 +32,42 @@ type Fake struct {
 	Packages           []*packages.Package
 	Package            *packages.Package
 	Target             *types.TypeName
+	TargetType         types.Type
 	Mode               FakeMode
 	DestinationPackage string
 	Name               string
 	TargetAlias        string
 	TargetName         string
 	TargetPackage      string
+	TargetTypeArgs     string
+	TypeParams         TypeParams
 	Imports            []Import
 	Methods            []Method
 	Function           Method
 	WorkingDirectory   string
+	UseParamNames      bool
+	ShimTypeNames      []string
+	ShimTypes          []ShimType
+	PackageVarNames    []string
+	ExcludedVarNames   []string
+	Adapter            bool
+	DeepCopyArgs       bool
+	Strict             bool
+	Expectations       bool
+	SyncHooks          bool
+	RecordCalls        bool
+	InjectErrors       bool
+	CallRecorder       bool
+	ReturnsWhen        bool
+	ReturnsSequence    bool
+	Reset              bool
+	Delegate           bool
+	ContextErrors      bool
+	TemplatePath       string
+	Style              string
+	packageVars        []types.Object
+	ctx                context.Context
+	overlay            map[string][]byte
 }
 
 // Method is a method of the interface.
@@ -49,11 +79,90 @@ type Method struct {
 	Args        string
 	Returns     Returns
 	Rets        string
+	ParamNames  bool
+	// TakesContext is true if the first parameter of the method is a
+	// context.Context and its last result is an error.
+	TakesContext bool
+	// ReturnsError is true if the last result of the method is an error.
+	ReturnsError bool
+	// Var is, in package mode, the package-level variable or constant that
+	// the method gets, or sets when it has a parameter.
+	Var string
+}
+
+// StubArgs is the parameter list of the stub function for the method. The
+// parameters are only named when the names come from the source.
+func (m Method) StubArgs() string {
+	if m.ParamNames {
+		return m.Params.AsNamedArgsWithTypes()
+	}
+	return m.Params.AsArgs()
+}
+
+// ArgsForCallSignature is the result signature of the XArgsForCall helper. The
+// results are only named when the names come from the source.
+func (m Method) ArgsForCallSignature() string {
+	if !m.ParamNames || len(m.Params) == 0 {
+		return m.Params.AsReturnSignature()
+	}
+	params := []string{}
+	for i := range m.Params {
+		params = append(params, unexport(m.Params[i].Name)+" "+strings.Replace(m.Params[i].Type, "...", "[]", -1))
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
+
+// MatchesArgs is true if the method has both parameters and results, so that
+// its results can be stubbed for specific arguments with XReturnsWhen.
+func (m Method) MatchesArgs() bool {
+	return m.Params.HasLength() && m.Returns.HasLength()
+}
+
+// Option configures a Fake before its target is loaded.
+type Option func(*Fake)
+
+// WithPackages makes NewFake search the given, already loaded, packages for
+// the target instead of loading them itself.
+func WithPackages(pkgs []*packages.Package) Option {
+	return func(f *Fake) {
+		f.Packages = pkgs
+	}
+}
+
+// WithContext makes NewFake stop loading the packages when ctx is done.
+func WithContext(ctx context.Context) Option {
+	return func(f *Fake) {
+		f.ctx = ctx
+	}
+}
+
+// WithOverlay makes NewFake load the packages as if the files at the absolute
+// paths in overlay had the given contents, such as the code of an interface
+// that has been generated but not written.
+func WithOverlay(overlay map[string][]byte) Option {
+	return func(f *Fake) {
+		f.overlay = overlay
+	}
+}
+
+func (f *Fake) context() context.Context {
+	if f.ctx == nil {
+		return context.Background()
+	}
+	return f.ctx
+}
+
+// WithParamNames names the parameters and results of the generated methods
+// after the ones in the source, instead of argN and resultN.
+func WithParamNames() Option {
+	return func(f *Fake) {
+		f.UseParamNames = true
+	}
 }
 
 // NewFake returns a Fake that loads the package and finds the interface or the
 // function.
-func NewFake(fakeMode FakeMode, targetName string, packagePath string, fakeName string, destinationPackage string, workingDir string) (*Fake, error) {
+func NewFake(fakeMode FakeMode, targetName string, packagePath string, fakeName string, destinationPackage string, workingDir string, opts ...Option) (*Fake, error) {
 	f := &Fake{
 		TargetName:         targetName,
 		TargetPackage:      packagePath,
@@ -63,8 +172,19 @@ func NewFake(fakeMode FakeMode, targetName string, packagePath string, fakeName
 		WorkingDirectory:   workingDir,
 		Imports:            []Import{},
 	}
+	for i := range opts {
+		opts[i](f)
+	}
 
-	f.AddImport("sync", "sync")
+	if f.Style != "" {
+		f.addStyleImports()
+	} else {
+		f.AddImport("sync", "sync")
+		f.AddImport("reflect", "reflect")
+		if (f.Strict || f.Expectations) && f.Mode == InterfaceOrFunction {
+			f.AddImport("fmt", "fmt")
+		}
+	}
 	err := f.loadPackages()
 	if err != nil {
 		return nil, err
@@ -76,7 +196,18 @@ func NewFake(fakeMode FakeMode, targetName string, packagePath string, fakeName
 		return nil, err
 	}
 
-	if f.IsInterface() || f.Mode == Package {
+	err = f.findShimTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = f.findPackageVars()
+	if err != nil {
+		return nil, err
+	}
+
+	f.addImportsForTypeParams()
+	if f.IsInterface() || f.Mode == Package || f.Mode == Struct {
 		f.loadMethods()
 	}
 	if f.IsFunction() {
@@ -85,9 +216,84 @@ func NewFake(fakeMode FakeMode, targetName string, packagePath string, fakeName
 			return nil, err
 		}
 	}
+	f.loadTypeParams()
+	if f.Style == "" && !f.matchesArgs() && !f.DeepCopiesArgs() && !f.HasExpectations() {
+		f.removeImport("reflect")
+	}
+	if f.Style == "" && (f.HasSyncHooks() || f.RecordsCalls() && len(f.Methods) > 0) {
+		f.AddImport("time", "time")
+	}
+	if f.Style == "" && f.FailsAll() {
+		f.AddImport("rand", "math/rand")
+	}
+	err = f.checkHelperNames()
+	if err != nil {
+		return nil, err
+	}
 	return f, nil
 }
 
+// WithReturnsWhen adds XReturnsWhen, XReturnsWhenMatches and
+// XReturnsWhenStrict to the fake of an interface or function, which stub the
+// results of its methods for specific arguments.
+func WithReturnsWhen() Option {
+	return func(f *Fake) {
+		f.ReturnsWhen = true
+	}
+}
+
+// HasReturnsWhen is true if the results of the fake can be stubbed for
+// specific arguments, for the methods that MatchesArgs.
+func (f *Fake) HasReturnsWhen() bool {
+	return f.ReturnsWhen && f.Mode == InterfaceOrFunction && (f.IsInterface() || f.IsFunction())
+}
+
+// matchesArgs is true if the fake has methods that can be stubbed with
+// XReturnsWhen, which compare their arguments with reflect.DeepEqual.
+func (f *Fake) matchesArgs() bool {
+	if !f.HasReturnsWhen() {
+		return false
+	}
+	if f.IsFunction() {
+		return f.Function.MatchesArgs()
+	}
+	for i := range f.Methods {
+		if f.Methods[i].MatchesArgs() {
+			return true
+		}
+	}
+	return false
+}
+
+// DeclaresMethod is true if the interface of the fake has a method with the
+// given name. The fake leaves out the methods it adds, such as Reset, when
+// they would clash with the methods of the interface or the ones generated for
+// them.
+func (f *Fake) DeclaresMethod(name string) bool {
+	for i := range f.Methods {
+		if f.Methods[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// WithDelegate adds NewXWithDelegate to the fake of an interface, which builds
+// the fake around a real implementation of the interface.
+func WithDelegate() Option {
+	return func(f *Fake) {
+		f.Delegate = true
+	}
+}
+
+// Delegates is true if the fake can be built around a real implementation of
+// its interface with NewXWithDelegate, which it then calls for the methods
+// that nothing is stubbed for. The interface has to be exported for the fake
+// to refer to it.
+func (f *Fake) Delegates() bool {
+	return f.Delegate && f.Mode == InterfaceOrFunction && f.IsInterface() && isExported(f.TargetName)
+}
+
 // IsInterface indicates whether the fake is for an interface.
 func (f *Fake) IsInterface() bool {
 	if f.Target == nil || f.Target.Type() == nil {
@@ -132,24 +338,46 @@ func isExported(s string) bool {
 // goimports on the output.
 func (f *Fake) Generate(runImports bool) ([]byte, error) {
 	var tmpl *template.Template
-	if f.IsInterface() {
+	if f.Style != "" {
+		text, err := f.styleTemplate()
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Writing %s double %s for interface %s to package %s\n", f.Style, f.Name, f.TargetName, f.DestinationPackage)
+		tmpl = template.Must(template.New("fake").Funcs(interfaceFuncs).Parse(text))
+	} else if f.IsInterface() {
 		log.Printf("Writing fake %s for interface %s to package %s\n", f.Name, f.TargetName, f.DestinationPackage)
-		tmpl = template.Must(template.New("fake").Funcs(interfaceFuncs).Parse(interfaceTemplate))
+		tmpl = template.Must(template.New("fake").Funcs(interfaceFuncs).Parse(interfaceTemplate + callRecorderTemplate + sequencePolicyTemplate + deepCopyTemplate + strictTemplate + verifyTemplate))
 	}
 	if f.IsFunction() {
 		log.Printf("Writing fake %s for function %s to package %s\n", f.Name, f.TargetName, f.DestinationPackage)
-		tmpl = template.Must(template.New("fake").Funcs(functionFuncs).Parse(functionTemplate))
+		tmpl = template.Must(template.New("fake").Funcs(functionFuncs).Parse(functionTemplate + callRecorderTemplate + sequencePolicyTemplate + deepCopyTemplate + strictTemplate))
 	}
 	if f.Mode == Package {
 		log.Printf("Writing fake %s for package %s to package %s\n", f.Name, f.TargetPackage, f.DestinationPackage)
 		tmpl = template.Must(template.New("fake").Funcs(packageFuncs).Parse(packageTemplate))
 	}
+	if f.Mode == Struct {
+		log.Printf("Writing interface %s for struct %s to package %s\n", f.Name, f.TargetName, f.DestinationPackage)
+		tmpl = template.Must(template.New("interface").Parse(structTemplate))
+	}
 	if tmpl == nil {
 		return nil, errors.New("counterfeiter can only generate fakes for interfaces or specific functions")
 	}
+	if f.TemplatePath != "" {
+		custom, err := f.customTemplate(tmpl)
+		if err != nil {
+			return nil, err
+		}
+		if custom != nil {
+			tmpl = custom
+		}
+	}
 
 	b := &bytes.Buffer{}
-	tmpl.Execute(b, f)
+	if err := tmpl.Execute(b, f); err != nil {
+		return nil, err
+	}
 	if runImports {
 		return imports.Process("counterfeiter_temp_process_file", b.Bytes(), nil)
 	}
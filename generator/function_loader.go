@@ -6,7 +6,7 @@ import (
 )
 
 func (f *Fake) loadMethodForFunction() error {
-	t, ok := f.Target.Type().(*types.Named)
+	t, ok := f.TargetType.(*types.Named)
 	if !ok {
 		return errors.New("target is not a named type")
 	}
@@ -16,7 +16,7 @@ func (f *Fake) loadMethodForFunction() error {
 	}
 	f.addTypesForMethod(sig)
 	importsMap := f.importsMap()
-	function := methodForSignature(sig, f.Name, f.TargetAlias, f.TargetName, importsMap)
+	function := methodForSignature(sig, f.Name, f.TargetAlias, f.TargetName, importsMap, f.UseParamNames, f.DeepCopyArgs)
 	f.Function = function
 	return nil
 }
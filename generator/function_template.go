@@ -21,8 +21,8 @@ import (
 	{{- end}}
 )
 
-type {{.Name}} struct {
-	Stub func({{.Function.Params.AsArgs}}) {{.Function.Returns.AsReturnSignature}}
+type {{.Name}}{{.TypeParams.AsDecl}} struct {
+	Stub func({{.Function.StubArgs}}) {{.Function.Returns.AsReturnSignature}}
 	mutex sync.RWMutex
 	argsForCall []struct{
 		{{- range .Function.Params}}
@@ -40,12 +40,43 @@ type {{.Name}} struct {
 		{{UnExport .Name}} {{.Type}}
 		{{- end}}
 	}
+	{{- if .HasReturnsSequence}}
+	returnsSequence *{{.Name}}Sequence{{.TypeParams.AsArgs}}
+	{{- end}}
+	{{- if .IsStrict}}
+	returnsSet bool
+	{{- end}}
+	{{- end}}
+	{{- if and .HasReturnsWhen .Function.MatchesArgs}}
+	returnsWhen []struct{
+		args []interface{}
+		match func({{.Function.Params.AsArgs}}) bool
+		{{- range .Function.Returns}}
+		{{UnExport .Name}} {{.Type}}
+		{{- end}}
+	}
+	returnsWhenStrict bool
+	{{- end}}
+	{{- if .HasSyncHooks}}
+	block  <-chan struct{}
+	called chan struct{}
+	events []chan int
 	{{- end}}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
+	{{- if .HasCallRecorder}}
+	callRecorder {{template "callRecorder"}}
+	{{- end}}
+	{{- if .IsStrict}}
+	testingTB interface {
+		Helper()
+		Fatalf(format string, args ...interface{})
+	}
+	allowedUnstubbed map[string]bool
+	{{- end}}
 }
 
-func (fake *{{.Function.FakeName}}) Spy({{.Function.Params.AsNamedArgsWithTypes}}) {{.Function.Returns.AsReturnSignature}} {
+func (fake *{{.Function.FakeName}}{{.TypeParams.AsArgs}}) Spy({{.Function.Params.AsNamedArgsWithTypes}}) {{.Function.Returns.AsReturnSignature}} {
 	{{- range .Function.Params.Slices}}
 	var {{UnExport .Name}}Copy {{.Type}}
 	if {{UnExport .Name}} != nil {
@@ -53,40 +84,131 @@ func (fake *{{.Function.FakeName}}) Spy({{.Function.Params.AsNamedArgsWithTypes}
 		copy({{UnExport .Name}}Copy, {{UnExport .Name}})
 	}
 	{{- end}}
+	{{- range .Function.Params.DeepCopies}}
+	{{UnExport .Name}}Copy, _ := fake.deepCopy({{UnExport .Name}}).({{Replace .Type "..." "[]" -1}})
+	{{- end}}
 	fake.mutex.Lock()
 	{{if .Function.Returns.HasLength}}ret, specificReturn := fake.returnsOnCall[len(fake.argsForCall)]
+	{{if .HasReturnsSequence}}sequence := fake.returnsSequence
+	{{end}}{{if .IsStrict}}stubbed := fake.returnsSet
+	{{end}}{{end}}{{if and .HasReturnsWhen .Function.MatchesArgs}}rules, strict := fake.returnsWhen, fake.returnsWhenStrict
 	{{end}}fake.argsForCall = append(fake.argsForCall, struct{
 		{{- range .Function.Params}}
 		{{.Name}} {{if .IsVariadic}}{{Replace .Type "..." "[]" -1}}{{else}}{{.Type}}{{end}}
 		{{- end}}
 	}{ {{- .Function.Params.AsNamedArgs -}} })
-	fake.recordInvocation("{{.TargetName}}", []interface{}{ {{- if .Function.Params.HasLength}}{{.Function.Params.AsNamedArgs}}{{end -}} })
+	{{- if .HasSyncHooks}}
+	call, block, events := len(fake.argsForCall)-1, fake.block, fake.events
+	if fake.called != nil {
+		close(fake.called)
+		fake.called = nil
+	}
+	{{- end}}
+	{{if .HasCallRecorder}}notify := {{end}}fake.recordInvocation("{{.TargetName}}", []interface{}{ {{- if .Function.Params.HasLength}}{{.Function.Params.AsNamedArgs}}{{end -}} })
 	fake.mutex.Unlock()
+	{{- if .HasCallRecorder}}
+	notify()
+	{{- end}}
+	{{- if .HasSyncHooks}}
+	for i := range events {
+		select {
+		case events[i] <- call:
+		default:
+		}
+	}
+	if block != nil {
+		<-block
+	}
+	{{- end}}
 	if fake.Stub != nil {
 		{{if .Function.Returns.HasLength}}return fake.Stub({{.Function.Params.AsNamedArgsForInvocation}}){{else}}fake.Stub({{.Function.Params.AsNamedArgsForInvocation}}){{end}}
 	}
+	{{- if and .IsStrict (not .Function.Returns.HasLength)}} else {
+		fake.failUnstubbed("{{.TargetName}}", []interface{}{ {{- .Function.Params.AsNamedArgs -}} })
+	}
+	{{- end}}
 	{{- if .Function.Returns.HasLength}}
 	if specificReturn {
 		return {{.Function.Returns.WithPrefix "ret."}}
 	}
+	{{- if .HasReturnsSequence}}
+	if sequence != nil {
+		return sequence.next()
+	}
+	{{- end}}
+	{{- if and .HasReturnsWhen .Function.MatchesArgs}}
+	for i := len(rules) - 1; i >= 0; i-- {
+		rule := rules[i]
+		if rule.match != nil && rule.match({{.Function.Params.AsNamedArgsForInvocation}}) || rule.match == nil && reflect.DeepEqual(rule.args, []interface{}{ {{- .Function.Params.AsNamedArgs -}} }) {
+			return {{.Function.Returns.WithPrefix "rule."}}
+		}
+	}
+	if strict {
+		panic("{{.Name}}: no ReturnsWhen rule matches the arguments")
+	}
+	{{- end}}
+	{{- if .IsStrict}}
+	if !stubbed {
+		fake.failUnstubbed("{{.TargetName}}", []interface{}{ {{- .Function.Params.AsNamedArgs -}} })
+	}
+	{{- end}}
 	return {{.Function.Returns.WithPrefix "fake.returns."}}
 	{{- end}}
 }
 
-func (fake *{{.Function.FakeName}}) CallCount() int {
+func (fake *{{.Function.FakeName}}{{.TypeParams.AsArgs}}) CallCount() int {
 	fake.mutex.RLock()
 	defer fake.mutex.RUnlock()
 	return len(fake.argsForCall)
 }
 
-func (fake *{{.Function.FakeName}}) Calls(stub func({{.Function.Params.AsArgs}}) {{.Function.Returns.AsReturnSignature}}) {
+func (fake *{{.Function.FakeName}}{{.TypeParams.AsArgs}}) Calls(stub func({{.Function.StubArgs}}) {{.Function.Returns.AsReturnSignature}}) {
 	fake.mutex.Lock()
 	defer fake.mutex.Unlock()
 	fake.Stub = stub
 }
 
+{{if .HasSyncHooks -}}
+func (fake *{{.Function.FakeName}}{{.TypeParams.AsArgs}}) BlockUntil(release <-chan struct{}) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.block = release
+}
+
+func (fake *{{.Function.FakeName}}{{.TypeParams.AsArgs}}) WaitForCalls(n int, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		fake.mutex.Lock()
+		if len(fake.argsForCall) >= n {
+			fake.mutex.Unlock()
+			return true
+		}
+		if fake.called == nil {
+			fake.called = make(chan struct{})
+		}
+		called := fake.called
+		fake.mutex.Unlock()
+		select {
+		case <-called:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+func (fake *{{.Function.FakeName}}{{.TypeParams.AsArgs}}) CallEvents(buffer int) <-chan int {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	events := make(chan int, buffer)
+	fake.events = append(fake.events, events)
+	return events
+}
+
+{{end -}}
+
 {{if .Function.Params.HasLength -}}
-func (fake *{{.Function.FakeName}}) ArgsForCall(i int) {{.Function.Params.AsReturnSignature}} {
+func (fake *{{.Function.FakeName}}{{.TypeParams.AsArgs}}) ArgsForCall(i int) {{.Function.ArgsForCallSignature}} {
 	fake.mutex.RLock()
 	defer fake.mutex.RUnlock()
 	return {{.Function.Params.WithPrefix "fake.argsForCall[i]."}}
@@ -94,10 +216,16 @@ func (fake *{{.Function.FakeName}}) ArgsForCall(i int) {{.Function.Params.AsRetu
 {{- end}}
 
 {{if .Function.Returns.HasLength -}}
-func (fake *{{.Function.FakeName}}) Returns({{.Function.Returns.AsNamedArgsWithTypes}}) {
+func (fake *{{.Function.FakeName}}{{.TypeParams.AsArgs}}) Returns({{.Function.Returns.AsNamedArgsWithTypes}}) {
 	fake.mutex.Lock()
 	defer fake.mutex.Unlock()
 	fake.Stub = nil
+	{{- if .HasReturnsSequence}}
+	fake.returnsSequence = nil
+	{{- end}}
+	{{- if .IsStrict}}
+	fake.returnsSet = true
+	{{- end}}
 	fake.returns = struct {
 		{{- range .Function.Returns}}
 		{{UnExport .Name}} {{.Type}}
@@ -105,7 +233,7 @@ func (fake *{{.Function.FakeName}}) Returns({{.Function.Returns.AsNamedArgsWithT
 	}{ {{- .Function.Returns.AsNamedArgs -}} }
 }
 
-func (fake *{{.Function.FakeName}}) ReturnsOnCall(i int, {{.Function.Returns.AsNamedArgsWithTypes}}) {
+func (fake *{{.Function.FakeName}}{{.TypeParams.AsArgs}}) ReturnsOnCall(i int, {{.Function.Returns.AsNamedArgsWithTypes}}) {
 	fake.mutex.Lock()
 	defer fake.mutex.Unlock()
 	fake.Stub = nil
@@ -122,9 +250,117 @@ func (fake *{{.Function.FakeName}}) ReturnsOnCall(i int, {{.Function.Returns.AsN
 		{{- end}}
 	}{ {{- .Function.Returns.AsNamedArgs -}} }
 }
+{{- if .HasReturnsSequence}}
+
+type {{.Name}}Sequence{{.TypeParams.AsDecl}} struct {
+	fake    *{{.Name}}{{.TypeParams.AsArgs}}
+	results []struct {
+		{{- range .Function.Returns}}
+		{{UnExport .Name}} {{.Type}}
+		{{- end}}
+	}
+	calls     int
+	exhausted {{UnExport .Name}}SequencePolicy
+}
+
+func (fake *{{.Function.FakeName}}{{.TypeParams.AsArgs}}) ReturnsSequence() *{{.Name}}Sequence{{.TypeParams.AsArgs}} {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.Stub = nil
+	{{- if .IsStrict}}
+	fake.returnsSet = true
+	{{- end}}
+	fake.returnsSequence = &{{.Name}}Sequence{{.TypeParams.AsArgs}}{fake: fake}
+	return fake.returnsSequence
+}
+
+func (sequence *{{.Name}}Sequence{{.TypeParams.AsArgs}}) Then({{.Function.Returns.AsNamedArgsWithTypes}}) *{{.Name}}Sequence{{.TypeParams.AsArgs}} {
+	sequence.fake.mutex.Lock()
+	defer sequence.fake.mutex.Unlock()
+	sequence.results = append(sequence.results, struct {
+		{{- range .Function.Returns}}
+		{{UnExport .Name}} {{.Type}}
+		{{- end}}
+	}{ {{- .Function.Returns.AsNamedArgs -}} })
+	return sequence
+}
+
+func (sequence *{{.Name}}Sequence{{.TypeParams.AsArgs}}) RepeatLast() {
+	sequence.whenExhausted({{UnExport .Name}}RepeatLast)
+}
+
+func (sequence *{{.Name}}Sequence{{.TypeParams.AsArgs}}) Cycle() {
+	sequence.whenExhausted({{UnExport .Name}}Cycle)
+}
+
+func (sequence *{{.Name}}Sequence{{.TypeParams.AsArgs}}) ZeroValues() {
+	sequence.whenExhausted({{UnExport .Name}}ZeroValues)
+}
+
+func (sequence *{{.Name}}Sequence{{.TypeParams.AsArgs}}) Fail() {
+	sequence.whenExhausted({{UnExport .Name}}Fail)
+}
+
+func (sequence *{{.Name}}Sequence{{.TypeParams.AsArgs}}) whenExhausted(policy {{UnExport .Name}}SequencePolicy) {
+	sequence.fake.mutex.Lock()
+	defer sequence.fake.mutex.Unlock()
+	sequence.exhausted = policy
+}
+
+func (sequence *{{.Name}}Sequence{{.TypeParams.AsArgs}}) next() ({{.Function.Returns.AsNamedArgsWithTypes}}) {
+	sequence.fake.mutex.Lock()
+	defer sequence.fake.mutex.Unlock()
+	i := sequence.calls
+	sequence.calls++
+	switch {
+	case i < len(sequence.results):
+	case sequence.exhausted == {{UnExport .Name}}Fail:
+		panic("{{.Name}}: the ReturnsSequence is exhausted")
+	case sequence.exhausted == {{UnExport .Name}}ZeroValues || len(sequence.results) == 0:
+		return
+	case sequence.exhausted == {{UnExport .Name}}Cycle:
+		i %= len(sequence.results)
+	default:
+		i = len(sequence.results) - 1
+	}
+	return {{.Function.Returns.WithPrefix "sequence.results[i]."}}
+}
+{{- end}}
 {{- end}}
 
-func (fake *{{.Function.FakeName}}) Invocations() map[string][][]interface{} {
+{{if and .HasReturnsWhen .Function.MatchesArgs -}}
+func (fake *{{.Function.FakeName}}{{.TypeParams.AsArgs}}) ReturnsWhen({{.Function.Params.AsNamedArgsWithSliceTypes}}, {{.Function.Returns.AsNamedArgsWithTypes}}) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.returnsWhen = append(fake.returnsWhen, struct {
+		args []interface{}
+		match func({{.Function.Params.AsArgs}}) bool
+		{{- range .Function.Returns}}
+		{{UnExport .Name}} {{.Type}}
+		{{- end}}
+	}{args: []interface{}{ {{- .Function.Params.WithPrefix ""}}}, {{.Function.Returns.AsKeyedArgs}}})
+}
+
+func (fake *{{.Function.FakeName}}{{.TypeParams.AsArgs}}) ReturnsWhenMatches(match func({{.Function.Params.AsArgs}}) bool, {{.Function.Returns.AsNamedArgsWithTypes}}) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.returnsWhen = append(fake.returnsWhen, struct {
+		args []interface{}
+		match func({{.Function.Params.AsArgs}}) bool
+		{{- range .Function.Returns}}
+		{{UnExport .Name}} {{.Type}}
+		{{- end}}
+	}{match: match, {{.Function.Returns.AsKeyedArgs}}})
+}
+
+func (fake *{{.Function.FakeName}}{{.TypeParams.AsArgs}}) ReturnsWhenStrict() {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.returnsWhenStrict = true
+}
+{{- end}}
+
+func (fake *{{.Function.FakeName}}{{.TypeParams.AsArgs}}) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.mutex.RLock()
@@ -136,7 +372,55 @@ func (fake *{{.Function.FakeName}}) Invocations() map[string][][]interface{} {
 	return copiedInvocations
 }
 
-func (fake *{{.Name}}) recordInvocation(key string, args []interface{}) {
+{{if .HasReset -}}
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) Reset() {
+	fake.mutex.Lock()
+	fake.Stub = nil
+	fake.argsForCall = nil
+	{{- if .Function.Returns.HasLength}}
+	fake.returns = struct {
+		{{- range .Function.Returns}}
+		{{UnExport .Name}} {{.Type}}
+		{{- end}}
+	}{}
+	fake.returnsOnCall = nil
+	{{- if .HasReturnsSequence}}
+	fake.returnsSequence = nil
+	{{- end}}
+	{{- if .IsStrict}}
+	fake.returnsSet = false
+	{{- end}}
+	{{- end}}
+	{{- if and .HasReturnsWhen .Function.MatchesArgs}}
+	fake.returnsWhen = nil
+	fake.returnsWhenStrict = false
+	{{- end}}
+	{{- if .HasSyncHooks}}
+	fake.block = nil
+	fake.events = nil
+	{{- end}}
+	fake.mutex.Unlock()
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	fake.invocations = nil
+}
+
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) ResetCalls() {
+	fake.mutex.Lock()
+	fake.argsForCall = nil
+	{{- if and .HasReturnsSequence .Function.Returns.HasLength}}
+	if fake.returnsSequence != nil {
+		fake.returnsSequence.calls = 0
+	}
+	{{- end}}
+	fake.mutex.Unlock()
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	fake.invocations = nil
+}
+
+{{end -}}
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) recordInvocation(key string, args []interface{}){{if .HasCallRecorder}} func(){{end}} {
 	fake.invocationsMutex.Lock()
 	defer fake.invocationsMutex.Unlock()
 	if fake.invocations == nil {
@@ -146,9 +430,43 @@ func (fake *{{.Name}}) recordInvocation(key string, args []interface{}) {
 		fake.invocations[key] = [][]interface{}{}
 	}
 	fake.invocations[key] = append(fake.invocations[key], args)
+	{{- if .HasCallRecorder}}
+	recorder := fake.callRecorder
+	return func() {
+		if recorder != nil {
+			recorder.RecordCall(fake, key, args)
+		}
+	}
+	{{- end}}
+}
+
+{{if .HasCallRecorder -}}
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) SetCallRecorder(recorder {{template "callRecorder"}}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	fake.callRecorder = recorder
 }
 
+{{end -}}
+{{if .ReturnsSequences -}}
+{{template "sequencePolicy" .}}
+{{- end}}
+
+{{if .DeepCopiesArgs -}}
+{{template "deepCopy" .}}
+{{- end}}
+
+{{if .IsStrict -}}
+{{template "strict" .}}
+{{- end}}
+
 {{if IsExported .TargetName -}}
-var _ {{.TargetAlias}}.{{.TargetName}} = new({{.Name}}).Spy
+{{if .TypeParams.HasLength -}}
+func _{{.TypeParams.AsDecl}}() {
+	var _ {{.TargetAlias}}.{{.TargetName}}{{.TargetTypeArgs}} = new({{.Name}}{{.TypeParams.AsArgs}}).Spy
+}
+{{- else -}}
+var _ {{.TargetAlias}}.{{.TargetName}}{{.TargetTypeArgs}} = new({{.Name}}).Spy
+{{- end}}
 {{- end}}
 `
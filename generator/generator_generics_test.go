@@ -0,0 +1,105 @@
+package generator
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestGeneratorGenerics(t *testing.T) {
+	spec.Run(t, "GeneratorGenerics", testGeneratorGenerics, spec.Report(report.Terminal{}))
+}
+
+func testGeneratorGenerics(t *testing.T, when spec.G, it spec.S) {
+	var (
+		f   *Fake
+		err error
+	)
+
+	it.Before(func() {
+		RegisterTestingT(t)
+	})
+
+	when("the target is a generic interface", func() {
+		it.Before(func() {
+			f, err = NewFake(InterfaceOrFunction, "Cache", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeCache", "fixturesfakes", "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it("keeps the type parameters and their constraints", func() {
+			Expect(f.TypeParams).To(Equal(TypeParams{
+				{Name: "K", Constraint: "~string | ~int"},
+				{Name: "V", Constraint: "fmt.Stringer"},
+			}))
+			Expect(f.TypeParams.AsDecl()).To(Equal("[K ~string | ~int, V fmt.Stringer]"))
+			Expect(f.TargetTypeArgs).To(Equal("[K, V]"))
+		})
+
+		it("imports the packages used by the constraints", func() {
+			Expect(f.Imports).To(ContainElement(Import{Alias: "fmt", Path: "fmt"}))
+		})
+
+		it("uses the type parameters in the methods", func() {
+			for i := range f.Methods {
+				if f.Methods[i].Name != "Get" {
+					continue
+				}
+				Expect(f.Methods[i].Params[0].Type).To(Equal("K"))
+				Expect(f.Methods[i].Returns[0].Type).To(Equal("V"))
+			}
+		})
+	})
+
+	when("the target is an instance of a generic interface", func() {
+		it.Before(func() {
+			f, err = NewFake(InterfaceOrFunction, "Repository[User]", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeRepositoryUser", "fixturesfakes", "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it("is not generic itself", func() {
+			Expect(f.TypeParams).To(BeEmpty())
+			Expect(f.TargetName).To(Equal("Repository"))
+			Expect(f.TargetTypeArgs).To(Equal("[fixtures.User]"))
+		})
+
+		it("substitutes the type arguments in the methods, including embedded ones", func() {
+			Expect(f.Methods).To(HaveLen(3))
+			for i := range f.Methods {
+				if f.Methods[i].Name != "Get" {
+					continue
+				}
+				Expect(f.Methods[i].Returns[0].Type).To(Equal("fixtures.User"))
+			}
+		})
+	})
+
+	when("the type arguments refer to packages imported by the target's package", func() {
+		it("imports them", func() {
+			f, err = NewFake(InterfaceOrFunction, "Cache[string,*bytes.Buffer]", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeCacheStringBuffer", "fixturesfakes", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(f.TargetTypeArgs).To(Equal("[string, *bytes.Buffer]"))
+			Expect(f.Imports).To(ContainElement(Import{Alias: "bytes", Path: "bytes"}))
+		})
+	})
+
+	when("the target is a generic function type", func() {
+		it("fakes it with a generic fake", func() {
+			f, err = NewFake(InterfaceOrFunction, "Mapper", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeMapper", "fixturesfakes", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(f.IsFunction()).To(BeTrue())
+			Expect(f.TypeParams.AsArgs()).To(Equal("[T, U]"))
+			Expect(f.Function.Params.AsArgs()).To(Equal("T"))
+			Expect(f.Function.Returns.AsArgs()).To(Equal("U, error"))
+		})
+	})
+
+	when("type arguments are given for a type that is not generic", func() {
+		it("errors", func() {
+			f, err = NewFake(InterfaceOrFunction, "Something[int]", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeSomethingInt", "fixturesfakes", "")
+			Expect(err).To(HaveOccurred())
+			Expect(f).To(BeNil())
+		})
+	})
+}
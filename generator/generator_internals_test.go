@@ -1,7 +1,11 @@
 package generator
 
 import (
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"sort"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -54,9 +58,10 @@ func testGenerator(t *testing.T, when spec.G, it spec.S) {
 				Expect(f.Name).To(Equal("FakeFileInfo"))
 				Expect(f.Mode).To(Equal(InterfaceOrFunction))
 				Expect(f.DestinationPackage).To(Equal("osfakes"))
-				Expect(f.Imports).To(HaveLen(3))
+				Expect(f.Imports).To(HaveLen(4))
 				Expect(f.Imports).To(ConsistOf(
 					Import{Alias: "os", Path: "os"},
+					Import{Alias: "fs", Path: "io/fs"},
 					Import{Alias: "sync", Path: "sync"},
 					Import{Alias: "time", Path: "time"},
 				))
@@ -67,6 +72,296 @@ func testGenerator(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 
+		when("the target is a package", func() {
+			it("generates the interface of its functions and the shim", func() {
+				f, err = NewFake(Package, "", "os", "Os", "osshim", "")
+				Expect(err).NotTo(HaveOccurred())
+				b, err := f.Generate(false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(b)).To(ContainSubstring("//go:generate counterfeiter . Os\n"))
+				Expect(string(b)).To(ContainSubstring("type Os interface {"))
+				Expect(string(b)).To(ContainSubstring("var _ Os = new(OsShim)"))
+			})
+		})
+
+		when("the target is a package with shim types", func() {
+			it("loads the methods of the types", func() {
+				f, err = NewFake(Package, "", "os", "Os", "osshim", "", WithShimTypes("File", "Process"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(f.ShimTypes).To(HaveLen(2))
+				Expect(f.ShimTypes[0].Name).To(Equal("File"))
+				Expect(f.ShimTypes[0].Type).To(Equal("*os.File"))
+
+				var names []string
+				for i := range f.ShimTypes[0].Methods {
+					names = append(names, f.ShimTypes[0].Methods[i].Name)
+				}
+				Expect(names).To(ContainElement("Write"))
+				Expect(names).To(ContainElement("Close"))
+			})
+
+			it("returns the interface of the shim from functions returning the type", func() {
+				f, err = NewFake(Package, "", "os", "Os", "osshim", "", WithShimTypes("File"))
+				Expect(err).NotTo(HaveOccurred())
+				for i := range f.Methods {
+					if f.Methods[i].Name != "Open" {
+						continue
+					}
+					Expect(f.Methods[i].Returns[0]).To(Equal(Return{Name: "result1", Type: "File", Shim: "File"}))
+					Expect(f.Methods[i].Returns[1].Shim).To(BeEmpty())
+					Expect(f.Methods[i].Returns.AsShimmedResults()).To(Equal("NewFileShim(result1), result2"))
+				}
+			})
+
+			it("only shims exported struct types", func() {
+				_, err = NewFake(Package, "", "os", "Os", "osshim", "", WithShimTypes("Getenv"))
+				Expect(err).To(MatchError("cannot shim Getenv: it is not an exported type of package os"))
+				_, err = NewFake(Package, "", "os", "Os", "osshim", "", WithShimTypes("Signal"))
+				Expect(err).To(MatchError("cannot shim Signal: it is not a struct type"))
+			})
+
+			it("only shims types in package mode", func() {
+				_, err = NewFake(InterfaceOrFunction, "FileInfo", "os", "FakeFileInfo", "osfakes", "", WithShimTypes("File"))
+				Expect(err).To(MatchError("shim types can only be generated in package mode"))
+			})
+		})
+
+		when("the target is a package with variables to access", func() {
+			method := func(name string) *Method {
+				for i := range f.Methods {
+					if f.Methods[i].Name == name {
+						return &f.Methods[i]
+					}
+				}
+				return nil
+			}
+
+			it("adds a getter for each constant, and a getter and a setter for each variable", func() {
+				f, err = NewFake(Package, "", "os", "Os", "osshim", "", WithPackageVars("Stdout", "PathSeparator"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(method("Stdout").Returns).To(Equal(Returns{{Name: "result1", Type: "*os.File"}}))
+				Expect(method("Stdout").Var).To(Equal("Stdout"))
+				Expect(method("SetStdout").Params).To(Equal(Params{{Name: "arg1", Type: "*os.File"}}))
+				Expect(method("PathSeparator").Returns).To(Equal(Returns{{Name: "result1", Type: "rune"}}))
+				Expect(method("SetPathSeparator")).To(BeNil())
+
+				b, err := f.Generate(true)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(b)).To(ContainSubstring("\tSetStdout(arg1 *os.File)\n"))
+				Expect(string(b)).To(ContainSubstring("func (p *OsShim) Stdout() *os.File {\n\treturn os.Stdout\n}"))
+				Expect(string(b)).To(ContainSubstring("func (p *OsShim) SetStdout(arg1 *os.File) {\n\tos.Stdout = arg1\n}"))
+			})
+
+			it("accesses every exported variable and constant but the excluded ones", func() {
+				f, err = NewFake(Package, "", "os", "Os", "osshim", "", WithPackageVars("*"), WithoutPackageVars("Args"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(method("Stdin")).NotTo(BeNil())
+				Expect(method("ModeDir")).NotTo(BeNil())
+				Expect(method("Args")).To(BeNil())
+				Expect(method("SetArgs")).To(BeNil())
+			})
+
+			it("returns the interface of the shim from getters of the type", func() {
+				f, err = NewFake(Package, "", "os", "Os", "osshim", "", WithShimTypes("File"), WithPackageVars("Stdout"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(method("Stdout").Returns.AsShimmedResults()).To(Equal("NewFileShim(result1)"))
+			})
+
+			it("leaves out the setters of variables of a shimmed type", func() {
+				f, err = NewFake(Package, "", "os", "Os", "osshim", "", WithShimTypes("File"), WithPackageVars("Stdout", "Args"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(method("Stdout")).NotTo(BeNil())
+				Expect(method("SetStdout")).To(BeNil())
+				Expect(method("SetArgs")).NotTo(BeNil())
+			})
+
+			it("returns an untyped integer constant as an uint64 if it does not fit in an int", func() {
+				f, err = NewFake(Package, "", "math", "Math", "mathshim", "", WithPackageVars("MaxUint64", "MaxInt64"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(method("MaxUint64").Returns[0].Type).To(Equal("uint64"))
+				Expect(method("MaxInt64").Returns[0].Type).To(Equal("int"))
+			})
+
+			it("only accesses exported variables and constants", func() {
+				_, err = NewFake(Package, "", "os", "Os", "osshim", "", WithPackageVars("Getenv"))
+				Expect(err).To(MatchError("cannot access Getenv: it is not an exported variable or constant of package os"))
+			})
+
+			it("only accesses variables in package mode", func() {
+				_, err = NewFake(InterfaceOrFunction, "FileInfo", "os", "FakeFileInfo", "osfakes", "", WithPackageVars("Stdout"))
+				Expect(err).To(MatchError("accessors for variables can only be generated in package mode"))
+			})
+		})
+
+		when("an interface is extracted from a struct", func() {
+			it("loads the exported methods of the struct, including those with pointer receivers", func() {
+				f, err = NewFake(Struct, "Builder", "strings", "Builder", "mypackage", "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(f.IsStruct()).To(BeTrue())
+				Expect(f.StructType()).To(Equal("*strings.Builder"))
+
+				var names []string
+				for i := range f.Methods {
+					names = append(names, f.Methods[i].Name)
+				}
+				Expect(names).To(ContainElement("Len"))
+				Expect(names).To(ContainElement("WriteString"))
+				Expect(f.Imports).NotTo(ContainElement(Import{Alias: "reflect", Path: "reflect"}))
+			})
+
+			it("generates the interface and the adapter", func() {
+				f, err = NewFake(Struct, "Builder", "strings", "Builder", "mypackage", "", WithAdapter())
+				Expect(err).NotTo(HaveOccurred())
+				b, err := f.Generate(true)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(b)).To(ContainSubstring("type Builder interface {"))
+				Expect(string(b)).To(ContainSubstring("var _ Builder = (*strings.Builder)(nil)"))
+				Expect(string(b)).To(ContainSubstring("func NewBuilderAdapter(delegate *strings.Builder) Builder {"))
+				Expect(string(b)).To(ContainSubstring("return adapter.Delegate.WriteString(arg1)"))
+			})
+
+			it("only extracts interfaces from structs", func() {
+				_, err = NewFake(Struct, "Signal", "os", "Signal", "mypackage", "")
+				Expect(err).To(MatchError("cannot extract an interface from Signal because it is not a struct"))
+			})
+		})
+
+		when("the target has methods that can be stubbed for specific arguments", func() {
+			it("imports reflect to compare the arguments", func() {
+				f, err = NewFake(InterfaceOrFunction, "Something", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeSomething", "fixturesfakes", "", WithReturnsWhen())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(f.Imports).To(ContainElement(Import{Alias: "reflect", Path: "reflect"}))
+			})
+
+			it("does not import reflect unless the fake stubs results for specific arguments", func() {
+				f, err = NewFake(InterfaceOrFunction, "Something", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeSomething", "fixturesfakes", "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(f.Imports).NotTo(ContainElement(Import{Alias: "reflect", Path: "reflect"}))
+			})
+
+			it("does not import reflect for other fakes", func() {
+				f, err = NewFake(InterfaceOrFunction, "Signal", "os", "FakeSignal", "osfakes", "", WithReturnsWhen())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(f.Imports).NotTo(ContainElement(Import{Alias: "reflect", Path: "reflect"}))
+			})
+		})
+
+		when("the target has methods that return an error", func() {
+			it("imports math/rand to fail them at random", func() {
+				f, err = NewFake(InterfaceOrFunction, "Something", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeSomething", "fixturesfakes", "", WithInjectErrors())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(f.FailsAll()).To(BeTrue())
+				Expect(f.Imports).To(ContainElement(Import{Alias: "rand", Path: "math/rand"}))
+			})
+
+			it("does not import math/rand unless the fake injects errors", func() {
+				f, err = NewFake(InterfaceOrFunction, "Something", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeSomething", "fixturesfakes", "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(f.FailsAll()).To(BeFalse())
+				Expect(f.Imports).NotTo(ContainElement(Import{Alias: "rand", Path: "math/rand"}))
+			})
+
+			it("does not import math/rand for other fakes", func() {
+				f, err = NewFake(InterfaceOrFunction, "Signal", "os", "FakeSignal", "osfakes", "", WithInjectErrors())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(f.FailsAll()).To(BeFalse())
+				Expect(f.Imports).NotTo(ContainElement(Import{Alias: "rand", Path: "math/rand"}))
+			})
+		})
+
+		when("the target has methods that take a context", func() {
+			it("finds the ones that return an error", func() {
+				f, err = NewFake(InterfaceOrFunction, "ContextAware", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeContextAware", "fixturesfakes", "", WithContextErrors())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(f.TakesContext()).To(BeTrue())
+				for _, method := range f.Methods {
+					Expect(method.TakesContext).To(Equal(method.Name != "Name"), method.Name)
+				}
+			})
+
+			it("leaves out the other fakes", func() {
+				f, err = NewFake(InterfaceOrFunction, "Something", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeSomething", "fixturesfakes", "", WithContextErrors())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(f.TakesContext()).To(BeFalse())
+			})
+
+			it("leaves out the fakes that are not generated to return the errors of the context", func() {
+				f, err = NewFake(InterfaceOrFunction, "ContextAware", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeContextAware", "fixturesfakes", "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(f.TakesContext()).To(BeFalse())
+			})
+		})
+
+		when("the packages have already been loaded", func() {
+			it("uses them instead of loading the target again", func() {
+				pkgs, err := LoadPackages("", "os")
+				Expect(err).NotTo(HaveOccurred())
+				f, err = NewFake(InterfaceOrFunction, "FileInfo", "os", "FakeFileInfo", "osfakes", "", WithPackages(pkgs))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(f.Packages).To(Equal(pkgs))
+				Expect(f.Methods).To(HaveLen(6))
+
+				f, err = NewFake(InterfaceOrFunction, "Signal", "os", "FakeSignal", "osfakes", "", WithPackages(pkgs))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(f.Packages).To(Equal(pkgs))
+				Expect(f.Methods).To(HaveLen(2))
+			})
+		})
+
+		when("listing the interfaces of the packages", func() {
+			it("returns the exported interfaces declared in them", func() {
+				pkgs, err := LoadPackages("", "github.com/maxbrunsfeld/counterfeiter/fixtures")
+				Expect(err).NotTo(HaveOccurred())
+				names, err := Interfaces(pkgs)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(names).To(ContainElement("Something"))
+				Expect(names).To(ContainElement("SomethingElse"))
+				Expect(names).NotTo(ContainElement("unexportedInterface"))
+				Expect(names).NotTo(ContainElement("User"))
+				Expect(sort.StringsAreSorted(names)).To(BeTrue())
+			})
+		})
+
+		when("the parameter names from the source are used", func() {
+			it.Before(func() {
+				f, err = NewFake(InterfaceOrFunction, "NamedParams", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeNamedParams", "fixturesfakes", "", WithParamNames())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(f.Methods).To(HaveLen(3))
+			})
+
+			method := func(name string) Method {
+				for i := range f.Methods {
+					if f.Methods[i].Name == name {
+						return f.Methods[i]
+					}
+				}
+				return Method{}
+			}
+
+			names := func(m Method) []string {
+				var result []string
+				for i := range m.Params {
+					result = append(result, m.Params[i].Name)
+				}
+				for i := range m.Returns {
+					result = append(result, m.Returns[i].Name)
+				}
+				return result
+			}
+
+			it("keeps the names of the parameters and results", func() {
+				Expect(names(method("DoThings"))).To(Equal([]string{"name", "count", "n", "err"}))
+			})
+
+			it("renames parameters that would clash with the generated code", func() {
+				Expect(names(method("Shadows"))).To(Equal([]string{"ctx", "arg2", "arg3", "arg4", "result1"}))
+			})
+
+			it("falls back to argN for blank names", func() {
+				Expect(names(method("Unnamed"))).To(Equal([]string{"arg1", "arg2", "data", "url", "items", "result1", "result2"}))
+			})
+		})
+
 		when("the target is a function that exists", func() {
 			it("succeeds", func() {
 				f, err = NewFake(InterfaceOrFunction, "HandlerFunc", "net/http", "FakeHandlerFunc", "httpfakes", "")
@@ -96,6 +391,101 @@ func testGenerator(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("generating a fake with a custom template", func() {
+		var dir string
+
+		it.Before(func() {
+			dir, err = ioutil.TempDir("", "counterfeiter-template")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "interface.tmpl"), []byte(`{{template "interface" .}}
+
+// New{{.Name}} returns a {{.Name}} for {{.TargetAlias}}.{{.TargetName}}.
+func New{{.Name}}() *{{.Name}} {
+	return new({{.Name}})
+}
+{{range .Methods}}
+// {{UnExport .Name}} has {{len .Params}} parameters and returns {{.Returns.AsReturnSignature}}.
+{{- end}}
+`), 0644)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		it("executes the template with the fake", func() {
+			f, err = NewFake(InterfaceOrFunction, "Signal", "os", "FakeSignal", "osfakes", "", WithTemplate(filepath.Join(dir, "interface.tmpl")))
+			Expect(err).NotTo(HaveOccurred())
+			b, err := f.Generate(true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("type FakeSignal struct {"))
+			Expect(string(b)).To(ContainSubstring("// NewFakeSignal returns a FakeSignal for os.Signal.\nfunc NewFakeSignal() *FakeSignal {"))
+			Expect(string(b)).To(ContainSubstring("// string has 0 parameters and returns string."))
+		})
+
+		it("keeps the built-in templates for the kinds of fakes the directory has none for", func() {
+			f, err = NewFake(InterfaceOrFunction, "HandlerFunc", "net/http", "FakeHandlerFunc", "httpfakes", "", WithTemplate(dir))
+			Expect(err).NotTo(HaveOccurred())
+			b, err := f.Generate(true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("type FakeHandlerFunc struct {"))
+			Expect(string(b)).NotTo(ContainSubstring("NewFakeHandlerFunc"))
+		})
+
+		it("reports a template that cannot be parsed", func() {
+			path := filepath.Join(dir, "broken.tmpl")
+			Expect(ioutil.WriteFile(path, []byte("{{.Name"), 0644)).To(Succeed())
+			f, err = NewFake(InterfaceOrFunction, "Signal", "os", "FakeSignal", "osfakes", "", WithTemplate(path))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = f.Generate(true)
+			Expect(err).To(MatchError(ContainSubstring("cannot parse the template " + path)))
+		})
+
+		it("reports a template that cannot be executed", func() {
+			path := filepath.Join(dir, "broken.tmpl")
+			Expect(ioutil.WriteFile(path, []byte("{{.NoSuchField}}"), 0644)).To(Succeed())
+			f, err = NewFake(InterfaceOrFunction, "Signal", "os", "FakeSignal", "osfakes", "", WithTemplate(path))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = f.Generate(true)
+			Expect(err).To(MatchError(ContainSubstring("can't evaluate field NoSuchField")))
+		})
+	})
+
+	when("generating a double in another style", func() {
+		it("generates a testify double", func() {
+			f, err = NewFake(InterfaceOrFunction, "HasVarArgs", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeHasVarArgs", "fixturesfakes", "", WithStyle("testify"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(f.Imports).To(ConsistOf(
+				Import{Alias: "mock", Path: "github.com/stretchr/testify/mock"},
+				Import{Alias: "fixtures", Path: "github.com/maxbrunsfeld/counterfeiter/fixtures"},
+			))
+			b, err := f.Generate(true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("type FakeHasVarArgs struct {\n\tmock.Mock\n}"))
+			Expect(string(b)).To(ContainSubstring("args := fake.Called(arg1, arg2)"))
+			Expect(string(b)).To(ContainSubstring("if value := args.Get(0); value != nil {\n\t\tresult1 = value.(int)\n\t}"))
+			Expect(string(b)).NotTo(ContainSubstring("sync.RWMutex"))
+		})
+
+		it("generates a gomock double", func() {
+			f, err = NewFake(InterfaceOrFunction, "HasVarArgs", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeHasVarArgs", "fixturesfakes", "", WithStyle("gomock"))
+			Expect(err).NotTo(HaveOccurred())
+			b, err := f.Generate(true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("func (fake *FakeHasVarArgs) EXPECT() *FakeHasVarArgsMockRecorder {"))
+			Expect(string(b)).To(ContainSubstring("ret := fake.ctrl.Call(fake, \"DoThings\", varargs...)"))
+			Expect(string(b)).To(ContainSubstring("func (recorder *FakeHasVarArgsMockRecorder) DoThings(arg1 interface{}, arg2 ...interface{}) *gomock.Call {"))
+			Expect(string(b)).To(ContainSubstring("reflect.TypeOf((*FakeHasVarArgs)(nil).DoThings)"))
+		})
+
+		it("only generates doubles of interfaces", func() {
+			f, err = NewFake(InterfaceOrFunction, "HandlerFunc", "net/http", "FakeHandlerFunc", "httpfakes", "", WithStyle("gomock"))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = f.Generate(true)
+			Expect(err).To(MatchError("a gomock double can only be generated for an interface"))
+		})
+	})
+
 	when("manually constructing a fake", func() {
 		it.Before(func() {
 			f = &Fake{}
@@ -113,6 +503,16 @@ func testGenerator(t *testing.T, when spec.G, it spec.S) {
 				Expect(f.Imports[0].Alias).To(Equal("sync"))
 				Expect(f.Imports[0].Path).To(Equal("sync"))
 			})
+
+			it("keeps the built-in reflect before the other imports", func() {
+				f.AddImport("reflect", "github.com/maxbrunsfeld/counterfeiter/fixtures/reflect")
+				f.AddImport("reflect", "reflect")
+				f.sortImports()
+				Expect(f.Imports[:2]).To(ConsistOf(
+					Import{Alias: "sync", Path: "sync"},
+					Import{Alias: "reflect", Path: "reflect"},
+				))
+			})
 		})
 
 		when("inspecting the target", func() {
@@ -144,6 +544,56 @@ func testGenerator(t *testing.T, when spec.G, it spec.S) {
 				it("IsFunction() is false", func() {
 					Expect(f.IsFunction()).To(BeFalse())
 				})
+
+				it("rejects expectations that clash with the methods of the interface", func() {
+					f.Expectations = true
+					f.Methods = []Method{{Name: "Close"}, {Name: "ExpectClose"}}
+					Expect(f.checkHelperNames()).To(MatchError("cannot generate a fake for FileInfo because its method ExpectClose would clash with the one the fake adds for Close"))
+
+					f.Methods = []Method{{Name: "Verify"}}
+					Expect(f.checkHelperNames()).To(MatchError("cannot generate a fake for FileInfo because its method Verify would clash with the one the fake adds for itself"))
+
+					f.Methods = []Method{{Name: "Close"}}
+					Expect(f.checkHelperNames()).To(Succeed())
+				})
+
+				it("rejects methods that clash with the ones the fake adds for the others", func() {
+					f.CallRecorder = true
+					f.Methods = []Method{{Name: "Close"}, {Name: "SetCallRecorder"}}
+					Expect(f.checkHelperNames()).To(MatchError("cannot generate a fake for FileInfo because its method SetCallRecorder would clash with the one the fake adds for itself"))
+
+					f.SyncHooks = true
+					f.Methods = []Method{{Name: "Close"}, {Name: "CloseBlockUntil"}}
+					Expect(f.checkHelperNames()).To(MatchError("cannot generate a fake for FileInfo because its method CloseBlockUntil would clash with the one the fake adds for Close"))
+
+					f.Reset = true
+					f.Methods = []Method{{Name: "Close"}, {Name: "CloseReset"}}
+					Expect(f.checkHelperNames()).To(MatchError("cannot generate a fake for FileInfo because its method CloseReset would clash with the one the fake adds for Close"))
+				})
+
+				it("only checks the helpers that the options of the fake add", func() {
+					f.Methods = []Method{{Name: "Close"}, {Name: "SetCallRecorder"}}
+					Expect(f.checkHelperNames()).To(Succeed())
+
+					f.Methods = []Method{{Name: "Close"}, {Name: "CloseBlockUntil"}}
+					Expect(f.checkHelperNames()).To(Succeed())
+
+					f.Methods = []Method{{Name: "Close"}, {Name: "CloseReset"}, {Name: "ResetCalls"}}
+					Expect(f.checkHelperNames()).To(Succeed())
+				})
+
+				it("rejects helpers that the fake would add twice", func() {
+					f.Expectations = true
+					f.SyncHooks = true
+					f.Methods = []Method{{Name: "Expect"}, {Name: "BlockUntil"}}
+					Expect(f.checkHelperNames()).To(MatchError("cannot generate a fake for FileInfo because the ExpectBlockUntil the fake adds for BlockUntil would clash with the one it adds for Expect"))
+				})
+
+				it("leaves out the helpers that the interface declares itself", func() {
+					f.Reset = true
+					f.Methods = []Method{{Name: "Reset"}, {Name: "Close"}}
+					Expect(f.checkHelperNames()).To(Succeed())
+				})
 			})
 
 			when("the target is a function", func() {
@@ -250,17 +700,19 @@ func testGenerator(t *testing.T, when spec.G, it spec.S) {
 					err := f.findPackage()
 					Expect(err).NotTo(HaveOccurred())
 					methods := packageMethodSet(f.Package)
-					Expect(len(methods)).To(BeNumerically(">=", 51)) // yes, this is crazy because go 1.11 added a function
-					Expect(len(methods)).To(BeNumerically("<=", 53))
+					Expect(len(methods)).To(BeNumerically(">=", 51)) // new releases of Go keep adding functions
 				})
 
 				it("can load the methods", func() {
 					err := f.findPackage()
 					Expect(err).NotTo(HaveOccurred())
 					f.loadMethods()
-					Expect(len(f.Methods)).To(BeNumerically(">=", 51)) // yes, this is crazy because go 1.11 added a function
-					Expect(len(f.Methods)).To(BeNumerically("<=", 53))
-					Expect(len(f.Imports)).To(Equal(2))
+					Expect(f.Methods).To(HaveLen(len(packageMethodSet(f.Package))))
+					Expect(f.Imports).To(ConsistOf(
+						Import{Alias: "time", Path: "time"},
+						Import{Alias: "fs", Path: "io/fs"},
+						Import{Alias: "os", Path: "os"},
+					))
 				})
 			})
 		})
@@ -400,6 +852,21 @@ func testGenerator(t *testing.T, when spec.G, it spec.S) {
 	})
 
 	when("helper functions", func() {
+		when("paramName()", func() {
+			it("rejects blank and invalid names", func() {
+				Expect(paramName("")).To(Equal(""))
+				Expect(paramName("_")).To(Equal(""))
+				Expect(paramName("1st")).To(Equal(""))
+			})
+
+			it("unexports the name", func() {
+				Expect(paramName("Name")).To(Equal("name"))
+				Expect(paramName("URL")).To(Equal("url"))
+				Expect(paramName("HTTPClient")).To(Equal("httpClient"))
+				Expect(paramName("count")).To(Equal("count"))
+			})
+		})
+
 		when("unexport()", func() {
 			it("is a no-op on an empty string", func() {
 				Expect(unexport("")).To(Equal(""))
@@ -0,0 +1,74 @@
+package generator
+
+// gomockTemplate generates a double of an interface that is driven by a
+// gomock.Controller, with a recorder for the expected calls, like the doubles
+// that mockgen generates.
+const gomockTemplate string = `// Code generated by counterfeiter. DO NOT EDIT.
+package {{.DestinationPackage}}
+
+import (
+	{{- range .Imports}}
+	{{.Alias}} "{{.Path}}"
+	{{- end}}
+)
+
+type {{.Name}}{{.TypeParams.AsDecl}} struct {
+	ctrl     *gomock.Controller
+	recorder *{{.Name}}MockRecorder{{.TypeParams.AsArgs}}
+}
+
+type {{.Name}}MockRecorder{{.TypeParams.AsDecl}} struct {
+	fake *{{.Name}}{{.TypeParams.AsArgs}}
+}
+
+func New{{.Name}}{{.TypeParams.AsDecl}}(ctrl *gomock.Controller) *{{.Name}}{{.TypeParams.AsArgs}} {
+	fake := &{{.Name}}{{.TypeParams.AsArgs}}{ctrl: ctrl}
+	fake.recorder = &{{.Name}}MockRecorder{{.TypeParams.AsArgs}}{fake: fake}
+	return fake
+}
+
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) EXPECT() *{{.Name}}MockRecorder{{.TypeParams.AsArgs}} {
+	return fake.recorder
+}
+
+{{range .Methods -}}
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}({{.Params.AsNamedArgsWithTypes}}) {{if .Returns.HasLength}}({{.Returns.AsNamedArgsWithTypes}}){{end}} {
+	fake.ctrl.T.Helper()
+	{{- if .IsVariadic}}
+	varargs := []interface{}{ {{- .FixedParams.WithPrefix ""}}}
+	for _, value := range {{.VariadicParam}} {
+		varargs = append(varargs, value)
+	}
+	{{if .Returns.HasLength}}ret := {{end}}fake.ctrl.Call(fake, "{{.Name}}", varargs...)
+	{{- else}}
+	{{if .Returns.HasLength}}ret := {{end}}fake.ctrl.Call(fake, "{{.Name}}"{{if .Params.HasLength}}, {{.Params.WithPrefix ""}}{{end}})
+	{{- end}}
+	{{- if .Returns.HasLength}}
+	{{- range $i, $ret := .Returns}}
+	{{UnExport $ret.Name}}, _ = ret[{{$i}}].({{$ret.Type}})
+	{{- end}}
+	return {{.Returns.WithPrefix ""}}
+	{{- end}}
+}
+
+func (recorder *{{.FakeName}}MockRecorder{{$.TypeParams.AsArgs}}) {{.Name}}({{.RecorderParams}}) *gomock.Call {
+	recorder.fake.ctrl.T.Helper()
+	{{- if .IsVariadic}}
+	varargs := append([]interface{}{ {{- .FixedParams.WithPrefix ""}}}, {{.VariadicParam}}...)
+	return recorder.fake.ctrl.RecordCallWithMethodType(recorder.fake, "{{.Name}}", reflect.TypeOf((*{{.FakeName}}{{$.TypeParams.AsArgs}})(nil).{{.Name}}), varargs...)
+	{{- else}}
+	return recorder.fake.ctrl.RecordCallWithMethodType(recorder.fake, "{{.Name}}", reflect.TypeOf((*{{.FakeName}}{{$.TypeParams.AsArgs}})(nil).{{.Name}}){{if .Params.HasLength}}, {{.Params.WithPrefix ""}}{{end}})
+	{{- end}}
+}
+
+{{end -}}
+{{if IsExported .TargetName -}}
+{{if .TypeParams.HasLength -}}
+func _{{.TypeParams.AsDecl}}() {
+	var _ {{.TargetAlias}}.{{.TargetName}}{{.TargetTypeArgs}} = new({{.Name}}{{.TypeParams.AsArgs}})
+}
+{{- else -}}
+var _ {{.TargetAlias}}.{{.TargetName}}{{.TargetTypeArgs}} = new({{.Name}})
+{{- end}}
+{{- end}}
+`
@@ -31,14 +31,28 @@ func (f *Fake) AddImport(alias string, path string) Import {
 	return result
 }
 
+// removeImport removes the import with the given path from Fake.Imports.
+func (f *Fake) removeImport(path string) {
+	for i := range f.Imports {
+		if f.Imports[i].Path == path {
+			f.Imports = append(f.Imports[:i], f.Imports[i+1:]...)
+			return
+		}
+	}
+}
+
+// isTemplateImport is true for the packages the templates refer to by their
+// own name, which must keep it when aliases are disambiguated.
+func isTemplateImport(path string) bool {
+	return path == "sync" || path == "reflect" || path == "fmt" || path == "time" || path == "math/rand" ||
+		path == "github.com/stretchr/testify/mock" || path == "go.uber.org/mock/gomock"
+}
+
 // SortImports sorts imports alphabetically.
 func (f *Fake) sortImports() {
 	sort.SliceStable(f.Imports, func(i, j int) bool {
-		if f.Imports[i].Path == "sync" {
-			return true
-		}
-		if f.Imports[j].Path == "sync" {
-			return false
+		if isTemplateImport(f.Imports[i].Path) != isTemplateImport(f.Imports[j].Path) {
+			return isTemplateImport(f.Imports[i].Path)
 		}
 		return f.Imports[i].Path < f.Imports[j].Path
 	})
@@ -3,6 +3,7 @@ package generator
 import (
 	"fmt"
 	"go/types"
+	"sort"
 	"strings"
 
 	"golang.org/x/tools/go/types/typeutil"
@@ -19,7 +20,12 @@ func (f *Fake) addTypesForMethod(sig *types.Signature) {
 	}
 }
 
-func methodForSignature(sig *types.Signature, fakeName string, fakePackage string, methodName string, importsMap map[string]Import) Method {
+// methodForSignature builds the Method for sig. Parameters and results are
+// named argN and resultN, unless useParamNames is set, in which case the names
+// from the source are used wherever they are usable. With deepCopyArgs the
+// parameters that may share memory with the caller are deep copied.
+func methodForSignature(sig *types.Signature, fakeName string, fakePackage string, methodName string, importsMap map[string]Import, useParamNames bool, deepCopyArgs bool) Method {
+	names := newNameSet(importsMap)
 	params := []Param{}
 	for i := 0; i < sig.Params().Len(); i++ {
 		param := sig.Params().At(i)
@@ -28,29 +34,43 @@ func methodForSignature(sig *types.Signature, fakeName string, fakePackage strin
 		if isVariadic {
 			typ = "..." + typ[2:] // Change []string to ...string
 		}
+		name := fmt.Sprintf("arg%v", i+1)
+		isSlice := strings.HasPrefix(typ, "[]")
+		deepCopy := deepCopyArgs && needsDeepCopy(param.Type(), map[types.Type]bool{})
+		if useParamNames {
+			name = names.pick(param.Name(), name, isSlice || deepCopy)
+		}
 		p := Param{
-			Name:       fmt.Sprintf("arg%v", i+1),
+			Name:       name,
 			Type:       typ,
 			IsVariadic: isVariadic,
-			IsSlice:    strings.HasPrefix(typ, "[]"),
+			IsSlice:    isSlice,
+			DeepCopy:   deepCopy,
 		}
 		params = append(params, p)
 	}
 	returns := []Return{}
 	for i := 0; i < sig.Results().Len(); i++ {
 		ret := sig.Results().At(i)
+		name := fmt.Sprintf("result%v", i+1)
+		if useParamNames {
+			name = names.pick(ret.Name(), name, false)
+		}
 		r := Return{
-			Name: fmt.Sprintf("result%v", i+1),
+			Name: name,
 			Type: typeFor(ret.Type(), importsMap),
 		}
 		returns = append(returns, r)
 	}
 	return Method{
-		FakeName:    fakeName,
-		FakePackage: fakePackage,
-		Name:        methodName,
-		Returns:     returns,
-		Params:      params,
+		FakeName:     fakeName,
+		FakePackage:  fakePackage,
+		Name:         methodName,
+		Returns:      returns,
+		Params:       params,
+		ParamNames:   useParamNames,
+		TakesContext: takesContext(sig),
+		ReturnsError: returnsError(sig),
 	}
 }
 
@@ -88,22 +108,47 @@ func interfaceMethodSet(t types.Type) []*rawMethod {
 
 func (f *Fake) loadMethods() {
 	var methods []*rawMethod
-	if f.Mode == Package {
+	switch {
+	case f.Mode == Package:
 		methods = packageMethodSet(f.Package)
-	} else {
-		if !f.IsInterface() || f.Target == nil || f.Target.Type() == nil {
+	case f.Mode == Struct:
+		methods = typeMethodSet(f.TargetType)
+	default:
+		if !f.IsInterface() || f.TargetType == nil {
 			return
 		}
-		methods = interfaceMethodSet(f.Target.Type())
+		methods = interfaceMethodSet(f.TargetType)
 	}
 
+	accessors := f.packageVarAccessors()
 	for i := range methods {
 		f.addTypesForMethod(methods[i].Signature)
 	}
+	for i := range accessors {
+		f.addTypesForMethod(accessors[i].Signature)
+	}
+	for i := range f.ShimTypes {
+		for _, m := range typeMethodSet(f.ShimTypes[i].obj.Type()) {
+			f.addTypesForMethod(m.Signature)
+		}
+	}
 
 	importsMap := f.importsMap()
 	for i := range methods {
-		method := methodForSignature(methods[i].Signature, f.Name, f.TargetAlias, methods[i].Func.Name(), importsMap)
+		method := methodForSignature(methods[i].Signature, f.Name, f.TargetAlias, methods[i].Func.Name(), importsMap, f.UseParamNames, f.DeepCopyArgs)
+		f.shimResults(&method, methods[i].Signature)
 		f.Methods = append(f.Methods, method)
 	}
+	for i := range accessors {
+		method := methodForSignature(accessors[i].Signature, f.Name, f.TargetAlias, accessors[i].Name, importsMap, f.UseParamNames, f.DeepCopyArgs)
+		method.Var = accessors[i].Var
+		f.shimResults(&method, accessors[i].Signature)
+		f.Methods = append(f.Methods, method)
+	}
+	if len(accessors) > 0 {
+		sort.SliceStable(f.Methods, func(i, j int) bool {
+			return f.Methods[i].Name < f.Methods[j].Name
+		})
+	}
+	f.loadShimTypes(importsMap)
 }
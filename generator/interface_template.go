@@ -21,15 +21,18 @@ import (
 	{{- end}}
 )
 
-type {{.Name}} struct {
+type {{.Name}}{{.TypeParams.AsDecl}} struct {
 	{{- range .Methods}}
-	{{.Name}}Stub func({{.Params.AsArgs}}) {{.Returns.AsReturnSignature}}
+	{{.Name}}Stub func({{.StubArgs}}) {{.Returns.AsReturnSignature}}
 	{{UnExport .Name}}Mutex sync.RWMutex
 	{{UnExport .Name}}ArgsForCall []struct{
 		{{- range .Params}}
 		{{.Name}} {{if .IsVariadic}}{{Replace .Type "..." "[]" -1}}{{else}}{{.Type}}{{end}}
 		{{- end}}
 	}
+	{{- if $.RecordsCalls}}
+	{{UnExport .Name}}CallRecords []*{{.FakeName}}{{.Name}}Call{{$.TypeParams.AsArgs}}
+	{{- end}}
 	{{- if .Returns.HasLength}}
 	{{UnExport .Name}}Returns struct{
 		{{- range .Returns}}
@@ -41,14 +44,65 @@ type {{.Name}} struct {
 		{{UnExport .Name}} {{.Type}}
 		{{- end}}
 	}
+	{{- if $.HasReturnsSequence}}
+	{{UnExport .Name}}ReturnsSequence *{{.FakeName}}{{.Name}}Sequence{{$.TypeParams.AsArgs}}
+	{{- end}}
+	{{- if or $.IsStrict $.Delegates}}
+	{{UnExport .Name}}ReturnsSet bool
+	{{- end}}
+	{{- end}}
+	{{- if and $.HasReturnsWhen .MatchesArgs}}
+	{{UnExport .Name}}ReturnsWhen []struct{
+		args []interface{}
+		match func({{.Params.AsArgs}}) bool
+		{{- range .Returns}}
+		{{UnExport .Name}} {{.Type}}
+		{{- end}}
+	}
+	{{UnExport .Name}}ReturnsWhenStrict bool
+	{{- end}}
+	{{- if $.HasExpectations}}
+	{{UnExport .Name}}Expectations []*{{.FakeName}}{{.Name}}Expectation{{$.TypeParams.AsArgs}}
+	{{- end}}
+	{{- if $.HasSyncHooks}}
+	{{UnExport .Name}}Block <-chan struct{}
+	{{UnExport .Name}}Called chan struct{}
+	{{UnExport .Name}}Events []chan int
 	{{- end}}
 	{{- end}}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
+	{{- if .HasCallRecorder}}
+	callRecorder {{template "callRecorder"}}
+	{{- end}}
+	{{- if .IsStrict}}
+	testingTB interface {
+		Helper()
+		Fatalf(format string, args ...interface{})
+	}
+	allowedUnstubbed map[string]bool
+	{{- end}}
+	{{- if .Delegates}}
+	delegate {{.TargetAlias}}.{{.TargetName}}{{.TargetTypeArgs}}
+	{{- end}}
+	{{- if .TakesContext}}
+	returnContextErrors bool
+	{{- end}}
+	{{- if .FailsAll}}
+	failure     error
+	failureRate float64
+	failureRand *rand.Rand
+	{{- end}}
+}
+
+{{if .Delegates -}}
+func New{{.Name}}WithDelegate{{.TypeParams.AsDecl}}(delegate {{.TargetAlias}}.{{.TargetName}}{{.TargetTypeArgs}}) *{{.Name}}{{.TypeParams.AsArgs}} {
+	return &{{.Name}}{{.TypeParams.AsArgs}}{delegate: delegate}
 }
+{{- end}}
 
 {{range .Methods -}}
-func (fake *{{.FakeName}}) {{.Name}}({{.Params.AsNamedArgsWithTypes}}) {{.Returns.AsReturnSignature}} {
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}({{.Params.AsNamedArgsWithTypes}}) {{if $.NamesResults .}}({{.Returns.AsNamedArgsWithTypes}}){{else}}{{.Returns.AsReturnSignature}}{{end}} {
 	{{- range .Params.Slices}}
 	var {{UnExport .Name}}Copy {{.Type}}
 	if {{UnExport .Name}} != nil {
@@ -56,45 +110,235 @@ func (fake *{{.FakeName}}) {{.Name}}({{.Params.AsNamedArgsWithTypes}}) {{.Return
 		copy({{UnExport .Name}}Copy, {{UnExport .Name}})
 	}
 	{{- end}}
+	{{- range .Params.DeepCopies}}
+	{{UnExport .Name}}Copy, _ := fake.deepCopy({{UnExport .Name}}).({{Replace .Type "..." "[]" -1}})
+	{{- end}}
 	fake.{{UnExport .Name}}Mutex.Lock()
 	{{- if .Returns.HasLength}}
 	ret, specificReturn := fake.{{UnExport .Name}}ReturnsOnCall[len(fake.{{UnExport .Name}}ArgsForCall)]
+	{{- if $.HasReturnsSequence}}
+	sequence := fake.{{UnExport .Name}}ReturnsSequence
+	{{- end}}
+	{{- if or $.IsStrict $.Delegates}}
+	stubbed := fake.{{UnExport .Name}}ReturnsSet
+	{{- end}}
+	{{- end}}
+	{{- if and $.HasReturnsWhen .MatchesArgs}}
+	rules, strict := fake.{{UnExport .Name}}ReturnsWhen, fake.{{UnExport .Name}}ReturnsWhenStrict
 	{{- end}}
 	fake.{{UnExport .Name}}ArgsForCall = append(fake.{{UnExport .Name}}ArgsForCall, struct{
 		{{- range .Params}}
 		{{.Name}} {{if .IsVariadic}}{{Replace .Type "..." "[]" -1}}{{else}}{{.Type}}{{end}}
 		{{- end}}
 	}{ {{- .Params.AsNamedArgs -}} })
-	fake.recordInvocation("{{.Name}}", []interface{}{ {{- if .Params.HasLength}}{{.Params.AsNamedArgs}}{{end -}} })
+	{{- if $.RecordsCalls}}
+	record := &{{.FakeName}}{{.Name}}Call{{$.TypeParams.AsArgs}}{ {{- if .Params.HasLength}}{{.CallRecordArgs}}, {{end}}Start: time.Now()}
+	fake.{{UnExport .Name}}CallRecords = append(fake.{{UnExport .Name}}CallRecords, record)
+	{{- end}}
+	{{- if $.HasSyncHooks}}
+	call, block, events := len(fake.{{UnExport .Name}}ArgsForCall)-1, fake.{{UnExport .Name}}Block, fake.{{UnExport .Name}}Events
+	if fake.{{UnExport .Name}}Called != nil {
+		close(fake.{{UnExport .Name}}Called)
+		fake.{{UnExport .Name}}Called = nil
+	}
+	{{- end}}
+	{{- if $.HasExpectations}}
+	var expectation *{{.FakeName}}{{.Name}}Expectation{{$.TypeParams.AsArgs}}
+	for i := range fake.{{UnExport .Name}}Expectations {
+		if fake.{{UnExport .Name}}Expectations[i].calls < fake.{{UnExport .Name}}Expectations[i].times && reflect.DeepEqual(fake.{{UnExport .Name}}Expectations[i].args, []interface{}{ {{- .Params.AsNamedArgs -}} }) {
+			expectation = fake.{{UnExport .Name}}Expectations[i]
+			expectation.calls++
+			break
+		}
+	}
+	{{- end}}
+	{{if $.HasCallRecorder}}notify := {{end}}fake.recordInvocation("{{.Name}}", []interface{}{ {{- if .Params.HasLength}}{{.Params.AsNamedArgs}}{{end -}} })
 	fake.{{UnExport .Name}}Mutex.Unlock()
+	{{- if $.HasCallRecorder}}
+	notify()
+	{{- end}}
+	{{- if $.RecordsCalls}}
+	defer func() {
+		recovered := recover()
+		fake.{{UnExport .Name}}Mutex.Lock()
+		{{- if .Returns.HasLength}}
+		{{.CallRecordResults "record."}} = {{.Returns.WithPrefix ""}}
+		{{- end}}
+		record.Duration = time.Since(record.Start)
+		record.Panic = recovered
+		fake.{{UnExport .Name}}Mutex.Unlock()
+		if recovered != nil {
+			panic(recovered)
+		}
+	}()
+	{{- end}}
+	{{- if $.HasSyncHooks}}
+	for i := range events {
+		select {
+		case events[i] <- call:
+		default:
+		}
+	}
+	{{- end}}
+	{{- if and $.TakesContext .TakesContext}}
+	if {{.ErrorResult}} = fake.contextErr({{.ContextArg}}, {{if $.HasSyncHooks}}block{{else}}nil{{end}}); {{.ErrorResult}} != nil {
+		return
+	}
+	{{- else if $.HasSyncHooks}}
+	if block != nil {
+		<-block
+	}
+	{{- end}}
 	if fake.{{.Name}}Stub != nil {
 		{{- if .Returns.HasLength}}
 		return fake.{{.Name}}Stub({{.Params.AsNamedArgsForInvocation}}){{else}}fake.{{.Name}}Stub({{.Params.AsNamedArgsForInvocation}})
 		{{- end}}
 	}
+	{{- if and $.Delegates (not .Returns.HasLength)}} else if fake.delegate != nil {
+		fake.delegate.{{.Name}}({{.Params.AsNamedArgsForInvocation}})
+	}
+	{{- end}}
+	{{- if and $.IsStrict (not .Returns.HasLength)}} else {{if $.HasExpectations}}if expectation == nil {{end}}{
+		fake.failUnstubbed("{{.Name}}", []interface{}{ {{- .Params.AsNamedArgs -}} })
+	}
+	{{- end}}
 	{{- if .Returns.HasLength}}
 	if specificReturn {
 		return {{.Returns.WithPrefix "ret."}}
 	}
+	{{- if $.HasExpectations}}
+	if expectation != nil && expectation.returns != nil {
+		return {{.Returns.WithPrefix "expectation.returns."}}
+	}
+	{{- end}}
+	{{- if $.HasReturnsSequence}}
+	if sequence != nil {
+		return sequence.next()
+	}
+	{{- end}}
+	{{- if and $.HasReturnsWhen .MatchesArgs}}
+	for i := len(rules) - 1; i >= 0; i-- {
+		rule := rules[i]
+		if rule.match != nil && rule.match({{.Params.AsNamedArgsForInvocation}}) || rule.match == nil && reflect.DeepEqual(rule.args, []interface{}{ {{- .Params.AsNamedArgs -}} }) {
+			return {{.Returns.WithPrefix "rule."}}
+		}
+	}
+	if strict {
+		panic("{{.FakeName}}.{{.Name}}: no {{.Name}}ReturnsWhen rule matches the arguments")
+	}
+	{{- end}}
+	{{- if and $.FailsAll .ReturnsError}}
+	if {{.ErrorResult}} = fake.injectedFailure(); {{.ErrorResult}} != nil {
+		return
+	}
+	{{- end}}
+	{{- if $.Delegates}}
+	if !stubbed && fake.delegate != nil {
+		return fake.delegate.{{.Name}}({{.Params.AsNamedArgsForInvocation}})
+	}
+	{{- end}}
+	{{- if $.IsStrict}}
+	if !stubbed {
+		fake.failUnstubbed("{{.Name}}", []interface{}{ {{- .Params.AsNamedArgs -}} })
+	}
+	{{- end}}
 	fakeReturns := fake.{{UnExport .Name}}Returns
 	return {{.Returns.WithPrefix "fakeReturns."}}
 	{{- end}}
 }
 
-func (fake *{{.FakeName}}) {{.Name}}CallCount() int {
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}CallCount() int {
 	fake.{{UnExport .Name}}Mutex.RLock()
 	defer fake.{{UnExport .Name}}Mutex.RUnlock()
 	return len(fake.{{UnExport .Name}}ArgsForCall)
 }
 
-func (fake *{{.FakeName}}) {{.Name}}Calls(stub func({{.Params.AsArgs}}) {{.Returns.AsReturnSignature}}) {
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}Calls(stub func({{.StubArgs}}) {{.Returns.AsReturnSignature}}) {
 	fake.{{UnExport .Name}}Mutex.Lock()
 	defer fake.{{UnExport .Name}}Mutex.Unlock()
 	fake.{{.Name}}Stub = stub
 }
 
+{{if $.HasSyncHooks -}}
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}BlockUntil(release <-chan struct{}) {
+	fake.{{UnExport .Name}}Mutex.Lock()
+	defer fake.{{UnExport .Name}}Mutex.Unlock()
+	fake.{{UnExport .Name}}Block = release
+}
+
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}WaitForCalls(n int, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		fake.{{UnExport .Name}}Mutex.Lock()
+		if len(fake.{{UnExport .Name}}ArgsForCall) >= n {
+			fake.{{UnExport .Name}}Mutex.Unlock()
+			return true
+		}
+		if fake.{{UnExport .Name}}Called == nil {
+			fake.{{UnExport .Name}}Called = make(chan struct{})
+		}
+		called := fake.{{UnExport .Name}}Called
+		fake.{{UnExport .Name}}Mutex.Unlock()
+		select {
+		case <-called:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}CallEvents(buffer int) <-chan int {
+	fake.{{UnExport .Name}}Mutex.Lock()
+	defer fake.{{UnExport .Name}}Mutex.Unlock()
+	events := make(chan int, buffer)
+	fake.{{UnExport .Name}}Events = append(fake.{{UnExport .Name}}Events, events)
+	return events
+}
+
+{{end -}}
+{{if $.HasReset -}}
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}Reset() {
+	fake.{{UnExport .Name}}Mutex.Lock()
+	fake.{{.Name}}Stub = nil
+	fake.{{UnExport .Name}}ArgsForCall = nil
+	{{- if $.RecordsCalls}}
+	fake.{{UnExport .Name}}CallRecords = nil
+	{{- end}}
+	{{- if .Returns.HasLength}}
+	fake.{{UnExport .Name}}Returns = struct {
+		{{- range .Returns}}
+		{{UnExport .Name}} {{.Type}}
+		{{- end}}
+	}{}
+	fake.{{UnExport .Name}}ReturnsOnCall = nil
+	{{- if $.HasReturnsSequence}}
+	fake.{{UnExport .Name}}ReturnsSequence = nil
+	{{- end}}
+	{{- if or $.IsStrict $.Delegates}}
+	fake.{{UnExport .Name}}ReturnsSet = false
+	{{- end}}
+	{{- end}}
+	{{- if and $.HasReturnsWhen .MatchesArgs}}
+	fake.{{UnExport .Name}}ReturnsWhen = nil
+	fake.{{UnExport .Name}}ReturnsWhenStrict = false
+	{{- end}}
+	{{- if $.HasExpectations}}
+	fake.{{UnExport .Name}}Expectations = nil
+	{{- end}}
+	{{- if $.HasSyncHooks}}
+	fake.{{UnExport .Name}}Block = nil
+	fake.{{UnExport .Name}}Events = nil
+	{{- end}}
+	fake.{{UnExport .Name}}Mutex.Unlock()
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	delete(fake.invocations, "{{.Name}}")
+}
+
+{{end -}}
 {{if .Params.HasLength -}}
-func (fake *{{.FakeName}}) {{.Name}}ArgsForCall(i int) {{.Params.AsReturnSignature}} {
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}ArgsForCall(i int) {{.ArgsForCallSignature}} {
 	fake.{{UnExport .Name}}Mutex.RLock()
 	defer fake.{{UnExport .Name}}Mutex.RUnlock()
 	argsForCall := fake.{{UnExport .Name}}ArgsForCall[i]
@@ -102,11 +346,48 @@ func (fake *{{.FakeName}}) {{.Name}}ArgsForCall(i int) {{.Params.AsReturnSignatu
 }
 {{- end}}
 
+{{if $.RecordsCalls -}}
+type {{.FakeName}}{{.Name}}Call{{$.TypeParams.AsDecl}} struct {
+	{{- if or .Params.HasLength .Returns.HasLength}}
+	{{.CallRecordFields}}
+	{{- end}}
+	Start    time.Time
+	Duration time.Duration
+	Panic    interface{}
+}
+
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}CallRecords() []{{.FakeName}}{{.Name}}Call{{$.TypeParams.AsArgs}} {
+	fake.{{UnExport .Name}}Mutex.RLock()
+	defer fake.{{UnExport .Name}}Mutex.RUnlock()
+	records := make([]{{.FakeName}}{{.Name}}Call{{$.TypeParams.AsArgs}}, len(fake.{{UnExport .Name}}CallRecords))
+	for i, record := range fake.{{UnExport .Name}}CallRecords {
+		records[i] = *record
+	}
+	return records
+}
+
+{{if .Returns.HasLength -}}
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}ResultsForCall(i int) {{.Returns.AsReturnSignature}} {
+	fake.{{UnExport .Name}}Mutex.RLock()
+	defer fake.{{UnExport .Name}}Mutex.RUnlock()
+	record := fake.{{UnExport .Name}}CallRecords[i]
+	return {{.CallRecordResults "record."}}
+}
+
+{{end -}}
+{{- end}}
+
 {{if .Returns.HasLength -}}
-func (fake *{{.FakeName}}) {{.Name}}Returns({{.Returns.AsNamedArgsWithTypes}}) {
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}Returns({{.Returns.AsNamedArgsWithTypes}}) {
 	fake.{{UnExport .Name}}Mutex.Lock()
 	defer fake.{{UnExport .Name}}Mutex.Unlock()
 	fake.{{.Name}}Stub = nil
+	{{- if $.HasReturnsSequence}}
+	fake.{{UnExport .Name}}ReturnsSequence = nil
+	{{- end}}
+	{{- if or $.IsStrict $.Delegates}}
+	fake.{{UnExport .Name}}ReturnsSet = true
+	{{- end}}
 	fake.{{UnExport .Name}}Returns = struct {
 		{{- range .Returns}}
 		{{UnExport .Name}} {{.Type}}
@@ -114,7 +395,7 @@ func (fake *{{.FakeName}}) {{.Name}}Returns({{.Returns.AsNamedArgsWithTypes}}) {
 	}{ {{- .Returns.AsNamedArgs -}} }
 }
 
-func (fake *{{.FakeName}}) {{.Name}}ReturnsOnCall(i int, {{.Returns.AsNamedArgsWithTypes}}) {
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}ReturnsOnCall(i int, {{.Returns.AsNamedArgsWithTypes}}) {
 	fake.{{UnExport .Name}}Mutex.Lock()
 	defer fake.{{UnExport .Name}}Mutex.Unlock()
 	fake.{{.Name}}Stub = nil
@@ -132,10 +413,199 @@ func (fake *{{.FakeName}}) {{.Name}}ReturnsOnCall(i int, {{.Returns.AsNamedArgsW
 	}{ {{- .Returns.AsNamedArgs -}} }
 }
 
+{{if and $.InjectsErrors .ReturnsError -}}
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}FailsWith(err error) {
+	fake.{{UnExport .Name}}Mutex.Lock()
+	defer fake.{{UnExport .Name}}Mutex.Unlock()
+	fake.{{.Name}}Stub = nil
+	{{- if $.HasReturnsSequence}}
+	fake.{{UnExport .Name}}ReturnsSequence = nil
+	{{- end}}
+	{{- if or $.IsStrict $.Delegates}}
+	fake.{{UnExport .Name}}ReturnsSet = true
+	{{- end}}
+	fake.{{UnExport .Name}}Returns = struct {
+		{{- range .Returns}}
+		{{UnExport .Name}} {{.Type}}
+		{{- end}}
+	}{ {{- .ErrorResult}}: err}
+}
+
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}FailsOnCall(i int, err error) {
+	fake.{{UnExport .Name}}Mutex.Lock()
+	defer fake.{{UnExport .Name}}Mutex.Unlock()
+	fake.{{.Name}}Stub = nil
+	if fake.{{UnExport .Name}}ReturnsOnCall == nil {
+		fake.{{UnExport .Name}}ReturnsOnCall = make(map[int]struct {
+			{{- range .Returns}}
+			{{UnExport .Name}} {{.Type}}
+			{{- end}}
+		})
+	}
+	fake.{{UnExport .Name}}ReturnsOnCall[i] = struct {
+		{{- range .Returns}}
+		{{UnExport .Name}} {{.Type}}
+		{{- end}}
+	}{ {{- .ErrorResult}}: err}
+}
+
+{{end -}}
+{{if $.HasReturnsSequence -}}
+type {{.FakeName}}{{.Name}}Sequence{{$.TypeParams.AsDecl}} struct {
+	fake    *{{.FakeName}}{{$.TypeParams.AsArgs}}
+	results []struct {
+		{{- range .Returns}}
+		{{UnExport .Name}} {{.Type}}
+		{{- end}}
+	}
+	calls     int
+	exhausted {{UnExport .FakeName}}SequencePolicy
+}
+
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}ReturnsSequence() *{{.FakeName}}{{.Name}}Sequence{{$.TypeParams.AsArgs}} {
+	fake.{{UnExport .Name}}Mutex.Lock()
+	defer fake.{{UnExport .Name}}Mutex.Unlock()
+	fake.{{.Name}}Stub = nil
+	{{- if or $.IsStrict $.Delegates}}
+	fake.{{UnExport .Name}}ReturnsSet = true
+	{{- end}}
+	fake.{{UnExport .Name}}ReturnsSequence = &{{.FakeName}}{{.Name}}Sequence{{$.TypeParams.AsArgs}}{fake: fake}
+	return fake.{{UnExport .Name}}ReturnsSequence
+}
+
+func (sequence *{{.FakeName}}{{.Name}}Sequence{{$.TypeParams.AsArgs}}) Then({{.Returns.AsNamedArgsWithTypes}}) *{{.FakeName}}{{.Name}}Sequence{{$.TypeParams.AsArgs}} {
+	sequence.fake.{{UnExport .Name}}Mutex.Lock()
+	defer sequence.fake.{{UnExport .Name}}Mutex.Unlock()
+	sequence.results = append(sequence.results, struct {
+		{{- range .Returns}}
+		{{UnExport .Name}} {{.Type}}
+		{{- end}}
+	}{ {{- .Returns.AsNamedArgs -}} })
+	return sequence
+}
+
+func (sequence *{{.FakeName}}{{.Name}}Sequence{{$.TypeParams.AsArgs}}) RepeatLast() {
+	sequence.whenExhausted({{UnExport .FakeName}}RepeatLast)
+}
+
+func (sequence *{{.FakeName}}{{.Name}}Sequence{{$.TypeParams.AsArgs}}) Cycle() {
+	sequence.whenExhausted({{UnExport .FakeName}}Cycle)
+}
+
+func (sequence *{{.FakeName}}{{.Name}}Sequence{{$.TypeParams.AsArgs}}) ZeroValues() {
+	sequence.whenExhausted({{UnExport .FakeName}}ZeroValues)
+}
+
+func (sequence *{{.FakeName}}{{.Name}}Sequence{{$.TypeParams.AsArgs}}) Fail() {
+	sequence.whenExhausted({{UnExport .FakeName}}Fail)
+}
+
+func (sequence *{{.FakeName}}{{.Name}}Sequence{{$.TypeParams.AsArgs}}) whenExhausted(policy {{UnExport .FakeName}}SequencePolicy) {
+	sequence.fake.{{UnExport .Name}}Mutex.Lock()
+	defer sequence.fake.{{UnExport .Name}}Mutex.Unlock()
+	sequence.exhausted = policy
+}
+
+func (sequence *{{.FakeName}}{{.Name}}Sequence{{$.TypeParams.AsArgs}}) next() ({{.Returns.AsNamedArgsWithTypes}}) {
+	sequence.fake.{{UnExport .Name}}Mutex.Lock()
+	defer sequence.fake.{{UnExport .Name}}Mutex.Unlock()
+	i := sequence.calls
+	sequence.calls++
+	switch {
+	case i < len(sequence.results):
+	case sequence.exhausted == {{UnExport .FakeName}}Fail:
+		panic("{{.FakeName}}.{{.Name}}: the {{.Name}}ReturnsSequence is exhausted")
+	case sequence.exhausted == {{UnExport .FakeName}}ZeroValues || len(sequence.results) == 0:
+		return
+	case sequence.exhausted == {{UnExport .FakeName}}Cycle:
+		i %= len(sequence.results)
+	default:
+		i = len(sequence.results) - 1
+	}
+	return {{.Returns.WithPrefix "sequence.results[i]."}}
+}
+
+{{end -}}
+{{if and $.HasReturnsWhen .MatchesArgs -}}
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}ReturnsWhen({{.Params.AsNamedArgsWithSliceTypes}}, {{.Returns.AsNamedArgsWithTypes}}) {
+	fake.{{UnExport .Name}}Mutex.Lock()
+	defer fake.{{UnExport .Name}}Mutex.Unlock()
+	fake.{{UnExport .Name}}ReturnsWhen = append(fake.{{UnExport .Name}}ReturnsWhen, struct {
+		args []interface{}
+		match func({{.Params.AsArgs}}) bool
+		{{- range .Returns}}
+		{{UnExport .Name}} {{.Type}}
+		{{- end}}
+	}{args: []interface{}{ {{- .Params.WithPrefix ""}}}, {{.Returns.AsKeyedArgs}}})
+}
+
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}ReturnsWhenMatches(match func({{.Params.AsArgs}}) bool, {{.Returns.AsNamedArgsWithTypes}}) {
+	fake.{{UnExport .Name}}Mutex.Lock()
+	defer fake.{{UnExport .Name}}Mutex.Unlock()
+	fake.{{UnExport .Name}}ReturnsWhen = append(fake.{{UnExport .Name}}ReturnsWhen, struct {
+		args []interface{}
+		match func({{.Params.AsArgs}}) bool
+		{{- range .Returns}}
+		{{UnExport .Name}} {{.Type}}
+		{{- end}}
+	}{match: match, {{.Returns.AsKeyedArgs}}})
+}
+
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}ReturnsWhenStrict() {
+	fake.{{UnExport .Name}}Mutex.Lock()
+	defer fake.{{UnExport .Name}}Mutex.Unlock()
+	fake.{{UnExport .Name}}ReturnsWhenStrict = true
+}
+
+{{end -}}
+{{end -}}
+{{if $.HasExpectations -}}
+type {{.FakeName}}{{.Name}}Expectation{{$.TypeParams.AsDecl}} struct {
+	fake  *{{.FakeName}}{{$.TypeParams.AsArgs}}
+	args  []interface{}
+	times int
+	calls int
+	{{- if .Returns.HasLength}}
+	returns *struct {
+		{{- range .Returns}}
+		{{UnExport .Name}} {{.Type}}
+		{{- end}}
+	}
+	{{- end}}
+}
+
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) Expect{{.Name}}({{.Params.AsNamedArgsWithSliceTypes}}) *{{.FakeName}}{{.Name}}Expectation{{$.TypeParams.AsArgs}} {
+	fake.{{UnExport .Name}}Mutex.Lock()
+	defer fake.{{UnExport .Name}}Mutex.Unlock()
+	expectation := &{{.FakeName}}{{.Name}}Expectation{{$.TypeParams.AsArgs}}{fake: fake, args: []interface{}{ {{- .Params.WithPrefix ""}}}, times: 1}
+	fake.{{UnExport .Name}}Expectations = append(fake.{{UnExport .Name}}Expectations, expectation)
+	return expectation
+}
+
+func (expectation *{{.FakeName}}{{.Name}}Expectation{{$.TypeParams.AsArgs}}) Times(n int) *{{.FakeName}}{{.Name}}Expectation{{$.TypeParams.AsArgs}} {
+	expectation.fake.{{UnExport .Name}}Mutex.Lock()
+	defer expectation.fake.{{UnExport .Name}}Mutex.Unlock()
+	expectation.times = n
+	return expectation
+}
+
+{{if .Returns.HasLength -}}
+func (expectation *{{.FakeName}}{{.Name}}Expectation{{$.TypeParams.AsArgs}}) Return({{.Returns.AsNamedArgsWithTypes}}) *{{.FakeName}}{{.Name}}Expectation{{$.TypeParams.AsArgs}} {
+	expectation.fake.{{UnExport .Name}}Mutex.Lock()
+	defer expectation.fake.{{UnExport .Name}}Mutex.Unlock()
+	expectation.returns = &struct {
+		{{- range .Returns}}
+		{{UnExport .Name}} {{.Type}}
+		{{- end}}
+	}{ {{- .Returns.AsNamedArgs -}} }
+	return expectation
+}
+
+{{end -}}
 {{end -}}
 {{end}}
 
-func (fake *{{.Name}}) Invocations() map[string][][]interface{} {
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	{{- range .Methods}}
@@ -149,7 +619,110 @@ func (fake *{{.Name}}) Invocations() map[string][][]interface{} {
 	return copiedInvocations
 }
 
-func (fake *{{.Name}}) recordInvocation(key string, args []interface{}) {
+{{if and .HasReset (not (.DeclaresMethod "Reset")) -}}
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) Reset() {
+	{{- range .Methods}}
+	fake.{{.Name}}Reset()
+	{{- end}}
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	fake.invocations = nil
+	{{- if .FailsAll}}
+	fake.failure = nil
+	fake.failureRand = nil
+	{{- end}}
+}
+{{- end}}
+
+{{if and .HasReset (not (or (.DeclaresMethod "Reset") (.DeclaresMethod "ResetCalls"))) -}}
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) ResetCalls() {
+	{{- range .Methods}}
+	fake.{{UnExport .Name}}Mutex.Lock()
+	fake.{{UnExport .Name}}ArgsForCall = nil
+	{{- if $.RecordsCalls}}
+	fake.{{UnExport .Name}}CallRecords = nil
+	{{- end}}
+	{{- if .Returns.HasLength}}
+	{{- if $.HasReturnsSequence}}
+	if fake.{{UnExport .Name}}ReturnsSequence != nil {
+		fake.{{UnExport .Name}}ReturnsSequence.calls = 0
+	}
+	{{- end}}
+	{{- if $.HasExpectations}}
+	for i := range fake.{{UnExport .Name}}Expectations {
+		fake.{{UnExport .Name}}Expectations[i].calls = 0
+	}
+	{{- end}}
+	{{- end}}
+	fake.{{UnExport .Name}}Mutex.Unlock()
+	{{- end}}
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	fake.invocations = nil
+}
+{{- end}}
+
+{{if .FailsAll -}}
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) FailAll(err error) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	fake.failure = err
+	fake.failureRand = nil
+}
+
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) FailRandomly(seed int64, rate float64, err error) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	fake.failure = err
+	fake.failureRate = rate
+	fake.failureRand = rand.New(rand.NewSource(seed))
+}
+
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) injectedFailure() error {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.failureRand != nil && fake.failureRand.Float64() >= fake.failureRate {
+		return nil
+	}
+	return fake.failure
+}
+
+{{end -}}
+{{if .TakesContext -}}
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) ReturnContextErrors(enabled bool) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	fake.returnContextErrors = enabled
+}
+
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) contextErr(ctx interface {
+	Done() <-chan struct{}
+	Err() error
+}, block <-chan struct{}) error {
+	fake.invocationsMutex.RLock()
+	enabled := fake.returnContextErrors
+	fake.invocationsMutex.RUnlock()
+	if !enabled || ctx == nil {
+		if block != nil {
+			<-block
+		}
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if block != nil {
+		select {
+		case <-block:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+{{end -}}
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) recordInvocation(key string, args []interface{}){{if .HasCallRecorder}} func(){{end}} {
 	fake.invocationsMutex.Lock()
 	defer fake.invocationsMutex.Unlock()
 	if fake.invocations == nil {
@@ -159,9 +732,47 @@ func (fake *{{.Name}}) recordInvocation(key string, args []interface{}) {
 		fake.invocations[key] = [][]interface{}{}
 	}
 	fake.invocations[key] = append(fake.invocations[key], args)
+	{{- if .HasCallRecorder}}
+	recorder := fake.callRecorder
+	return func() {
+		if recorder != nil {
+			recorder.RecordCall(fake, key, args)
+		}
+	}
+	{{- end}}
+}
+
+{{if .HasCallRecorder -}}
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) SetCallRecorder(recorder {{template "callRecorder"}}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	fake.callRecorder = recorder
 }
 
+{{end -}}
+{{if .ReturnsSequences -}}
+{{template "sequencePolicy" .}}
+{{- end}}
+
+{{if .DeepCopiesArgs -}}
+{{template "deepCopy" .}}
+{{- end}}
+
+{{if .IsStrict -}}
+{{template "strict" .}}
+{{- end}}
+
+{{if .HasExpectations -}}
+{{template "verify" .}}
+{{- end}}
+
 {{if IsExported .TargetName -}}
-var _ {{.TargetAlias}}.{{.TargetName}} = new({{.Name}})
+{{if .TypeParams.HasLength -}}
+func _{{.TypeParams.AsDecl}}() {
+	var _ {{.TargetAlias}}.{{.TargetName}}{{.TargetTypeArgs}} = new({{.Name}}{{.TypeParams.AsArgs}})
+}
+{{- else -}}
+var _ {{.TargetAlias}}.{{.TargetName}}{{.TargetTypeArgs}} = new({{.Name}})
+{{- end}}
 {{- end}}
 `
@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"context"
 	"fmt"
 	"go/types"
 	"log"
@@ -11,36 +12,109 @@ import (
 )
 
 func (f *Fake) loadPackages() error {
+	if f.Packages != nil {
+		log.Printf("using %v preloaded packages\n", len(f.Packages))
+		return nil
+	}
+	p, err := loadPackages(f.context(), f.WorkingDirectory, f.TargetPackage, f.overlay)
+	if err != nil {
+		return err
+	}
+	f.Packages = p
+	log.Printf("loaded %v packages\n", len(f.Packages))
+	return nil
+}
+
+// LoadPackages loads the package graph for packagePath, relative to
+// workingDir. The result can be shared by every Fake that targets the same
+// package by passing it to NewFake with WithPackages.
+func LoadPackages(workingDir string, packagePath string) ([]*packages.Package, error) {
+	return LoadPackagesContext(context.Background(), workingDir, packagePath)
+}
+
+// LoadPackagesContext is like LoadPackages, but stops loading when ctx is
+// done.
+func LoadPackagesContext(ctx context.Context, workingDir string, packagePath string) ([]*packages.Package, error) {
+	return loadPackages(ctx, workingDir, packagePath, nil)
+}
+
+func loadPackages(ctx context.Context, workingDir string, packagePath string, overlay map[string][]byte) ([]*packages.Package, error) {
 	log.Println("loading packages...")
 	p, err := packages.Load(&packages.Config{
-		Mode:  packages.LoadSyntax,
-		Dir:   f.WorkingDirectory,
-		Tests: true,
-	}, f.TargetPackage)
+		Context: ctx,
+		Mode:    packages.LoadSyntax,
+		Dir:     workingDir,
+		Tests:   true,
+		Overlay: overlay,
+	}, packagePath)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	loadErr := &LoadError{}
 	for i := range p {
 		if len(p[i].Errors) > 0 {
 			if i == 0 {
-				err = p[i].Errors[0]
+				err = loadErr
 			}
 			for j := range p[i].Errors {
 				log.Printf("error loading packages: %v", strings.TrimPrefix(fmt.Sprintf("%v", p[i].Errors[j]), "-: "))
 			}
+			loadErr.Errors = append(loadErr.Errors, p[i].Errors...)
 		}
 	}
 	if err != nil {
-		return err
+		return nil, err
 	}
-	f.Packages = p
-	log.Printf("loaded %v packages\n", len(f.Packages))
-	return nil
+	return p, nil
+}
+
+// LoadError is returned by LoadPackages when the target package cannot be
+// loaded. It keeps every error reported by the loader, for all the packages.
+type LoadError struct {
+	Errors []packages.Error
+}
+
+func (e *LoadError) Error() string {
+	return e.Errors[0].Error()
+}
+
+// Interfaces returns the names of the exported interfaces declared in the
+// package loaded as pkgs by LoadPackages, in order, so that a fake can be
+// generated for each of them. Constraints, which nothing can implement, are
+// left out.
+func Interfaces(pkgs []*packages.Package) ([]string, error) {
+	var pkg *packages.Package
+	for i := range pkgs {
+		if pkgs[i].Types == nil {
+			continue
+		}
+		if pkg == nil || pkgs[i].ID == pkgs[i].PkgPath {
+			pkg = pkgs[i]
+		}
+	}
+	if pkg == nil {
+		return nil, fmt.Errorf("cannot find a package to list the interfaces of")
+	}
+
+	var result []string
+	scope := pkg.Types.Scope()
+	for _, name := range scope.Names() {
+		typeName, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || !typeName.Exported() || typeName.IsAlias() {
+			continue
+		}
+		iface, ok := typeName.Type().Underlying().(*types.Interface)
+		if ok && !isConstraint(iface) {
+			result = append(result, name)
+		}
+	}
+	return result, nil
 }
 
 func (f *Fake) findPackage() error {
 	var target *types.TypeName
 	var pkg *packages.Package
+	targetName, typeArgs := SplitTypeArgs(f.TargetName)
 	for i := range f.Packages {
 		if f.Packages[i].Types == nil || f.Packages[i].Types.Scope() == nil {
 			continue
@@ -50,7 +124,7 @@ func (f *Fake) findPackage() error {
 			break
 		}
 
-		raw := pkg.Types.Scope().Lookup(f.TargetName)
+		raw := pkg.Types.Scope().Lookup(targetName)
 		if raw != nil {
 			if typeName, ok := raw.(*types.TypeName); ok {
 				target = typeName
@@ -63,7 +137,7 @@ func (f *Fake) findPackage() error {
 		switch f.Mode {
 		case Package:
 			return fmt.Errorf("cannot find package with name: %s", f.TargetPackage)
-		case InterfaceOrFunction:
+		case InterfaceOrFunction, Struct:
 			return fmt.Errorf("cannot find package with target: %s", f.TargetName)
 		}
 	}
@@ -74,6 +148,15 @@ func (f *Fake) findPackage() error {
 	f.TargetAlias = t.Alias
 	if f.Mode != Package {
 		f.TargetName = target.Name()
+		f.TargetType = target.Type()
+	}
+	if typeArgs != "" {
+		instance, err := instantiate(pkg, targetName+typeArgs)
+		if err != nil {
+			return err
+		}
+		f.TargetType = instance
+		f.addImportsFor(instance)
 	}
 
 	if f.Mode == InterfaceOrFunction {
@@ -81,6 +164,9 @@ func (f *Fake) findPackage() error {
 			return fmt.Errorf("cannot generate an fake for %s because it is not an interface or function", f.TargetName)
 		}
 	}
+	if f.Mode == Struct && !f.IsStruct() {
+		return fmt.Errorf("cannot extract an interface from %s because it is not a struct", f.TargetName)
+	}
 
 	if f.IsInterface() {
 		log.Printf("Found interface with name: [%s]\n", f.TargetName)
@@ -91,6 +177,9 @@ func (f *Fake) findPackage() error {
 	if f.Mode == Package {
 		log.Printf("Found package with name: [%s]\n", f.TargetPackage)
 	}
+	if f.Mode == Struct {
+		log.Printf("Found struct with name: [%s]\n", f.TargetName)
+	}
 	return nil
 }
 
@@ -115,15 +204,34 @@ func (f *Fake) addImportsFor(typ types.Type) {
 		if t.Obj() != nil && t.Obj().Pkg() != nil {
 			f.AddImport(t.Obj().Pkg().Name(), t.Obj().Pkg().Path())
 		}
+		f.addImportsForTypeArgs(t)
 	case *types.Slice:
 		f.addImportsFor(t.Elem())
 	case *types.Array:
 		f.addImportsFor(t.Elem())
 	case *types.Interface:
-		return
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			if sig, ok := t.ExplicitMethod(i).Type().(*types.Signature); ok {
+				f.addTypesForMethod(sig)
+			}
+		}
+		f.addImportsForEmbedded(t)
 	case *types.Signature:
 		f.addTypesForMethod(t)
 	default:
+		if f.addImportsForGeneric(typ) {
+			return
+		}
+		// Aliases, such as os.FileMode, are printed with the package that
+		// declares them, in the versions of go/types that keep them.
+		if alias, ok := typ.(interface{ Obj() *types.TypeName }); ok && alias.Obj() != nil && alias.Obj().Pkg() != nil {
+			f.AddImport(alias.Obj().Pkg().Name(), alias.Obj().Pkg().Path())
+			return
+		}
+		if u := typ.Underlying(); u != nil && u != typ {
+			f.addImportsFor(u)
+			return
+		}
 		log.Printf("!!! WARNING: Missing case for type %s\n", reflect.TypeOf(typ).String())
 	}
 }
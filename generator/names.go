@@ -0,0 +1,220 @@
+package generator
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"unicode"
+	"unicode/utf8"
+)
+
+// reservedNames are the identifiers the templates declare inside the methods
+// of a fake, which parameter and result names must not shadow.
+var reservedNames = []string{
+	"fake",
+	"ret",
+	"specificReturn",
+	"fakeReturns",
+	"argsForCall",
+	"stub",
+	"i",
+	"rules",
+	"rule",
+	"strict",
+	"args",
+	"match",
+	"shim",
+	"adapter",
+	"stubbed",
+	"expectation",
+	"sequence",
+	"call",
+	"block",
+	"events",
+	"record",
+	"recovered",
+	"varargs",
+	"recorder",
+	"notify",
+	"value",
+}
+
+// nameSet tracks the identifiers that are in use in the methods of a fake, so
+// that the names given to parameters and results are unique.
+type nameSet map[string]bool
+
+func newNameSet(importsMap map[string]Import) nameSet {
+	s := nameSet{}
+	for i := range reservedNames {
+		s[reservedNames[i]] = true
+	}
+	for _, imp := range importsMap {
+		s[imp.Alias] = true
+	}
+	return s
+}
+
+func (s nameSet) taken(name string) bool {
+	return s[name] || types.Universe.Lookup(name) != nil || token.Lookup(name).IsKeyword()
+}
+
+// pick returns name when it is a usable identifier that is not taken yet, and
+// otherwise fallback, suffixed with a number if even that is taken. When slice
+// is true the "Copy" variable the templates declare for the name is also
+// reserved.
+func (s nameSet) pick(name string, fallback string, slice bool) string {
+	name = paramName(name)
+	free := func(n string) bool {
+		return !s.taken(n) && (!slice || !s.taken(n+"Copy"))
+	}
+	if name == "" || !free(name) {
+		name = fallback
+		for i := 2; !free(name); i++ {
+			name = fmt.Sprintf("%s_%v", fallback, i)
+		}
+	}
+	s[name] = true
+	if slice {
+		s[name+"Copy"] = true
+	}
+	return name
+}
+
+// paramName turns the name of a parameter or result from the source into an
+// unexported identifier, lower casing a leading initialism as a whole (URL
+// becomes url, HTTPClient becomes httpClient). The blank identifier and names
+// that are not valid identifiers become "".
+func paramName(name string) string {
+	if name == "" || name == "_" || !isIdentifier(name) {
+		return ""
+	}
+	runes := []rune(name)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+func isIdentifier(name string) bool {
+	for i, r := range name {
+		if r == '_' || unicode.IsLetter(r) {
+			continue
+		}
+		if i > 0 && unicode.IsDigit(r) {
+			continue
+		}
+		return false
+	}
+	return utf8.RuneCountInString(name) > 0
+}
+
+// helperNames are the names of the fields and methods that the options of the
+// fake of an interface add for each of its methods, and for the fake itself
+// under the empty name. The helpers that every fake has, such as XCallCount
+// and Invocations, and the ones it leaves out when the interface declares
+// them, such as Reset, are not included.
+func (f *Fake) helperNames() map[string][]string {
+	names := map[string][]string{}
+	if f.HasCallRecorder() {
+		names[""] = append(names[""], "SetCallRecorder")
+	}
+	if f.HasReset() && !f.DeclaresMethod("Reset") {
+		names[""] = append(names[""], "Reset")
+		if !f.DeclaresMethod("ResetCalls") {
+			names[""] = append(names[""], "ResetCalls")
+		}
+	}
+	if f.FailsAll() {
+		names[""] = append(names[""], "FailAll", "FailRandomly", "injectedFailure")
+	}
+	if f.TakesContext() {
+		names[""] = append(names[""], "ReturnContextErrors", "contextErr")
+	}
+	if f.IsStrict() {
+		names[""] = append(names[""], "SetTB", "AllowUnstubbed", "failUnstubbed")
+	}
+	if f.HasExpectations() {
+		names[""] = append(names[""], "Verify", "VerifyOnCleanup", "verifyCalls")
+	}
+	if f.DeepCopiesArgs() {
+		names[""] = append(names[""], "deepCopy")
+	}
+	for _, m := range f.Methods {
+		suffixes := []string{}
+		if f.HasReset() {
+			suffixes = append(suffixes, "Reset")
+		}
+		if f.HasReturnsSequence() && m.Returns.HasLength() {
+			suffixes = append(suffixes, "ReturnsSequence")
+		}
+		if f.HasReturnsWhen() && m.MatchesArgs() {
+			suffixes = append(suffixes, "ReturnsWhen", "ReturnsWhenMatches", "ReturnsWhenStrict")
+		}
+		if f.RecordsCalls() {
+			suffixes = append(suffixes, "CallRecords")
+			if m.Returns.HasLength() {
+				suffixes = append(suffixes, "ResultsForCall")
+			}
+		}
+		if f.HasSyncHooks() {
+			suffixes = append(suffixes, "BlockUntil", "WaitForCalls", "CallEvents")
+		}
+		if f.InjectsErrors() && m.ReturnsError {
+			suffixes = append(suffixes, "FailsWith", "FailsOnCall")
+		}
+		for _, suffix := range suffixes {
+			names[m.Name] = append(names[m.Name], m.Name+suffix)
+		}
+		if f.HasExpectations() {
+			names[m.Name] = append(names[m.Name], "Expect"+m.Name)
+		}
+	}
+	return names
+}
+
+// checkHelperNames returns an error when a method of the interface has the
+// name of one of the fields and methods the fake adds, or when the fake would
+// add two of them with the same name, instead of generating a fake that does
+// not compile.
+func (f *Fake) checkHelperNames() error {
+	if f.Mode != InterfaceOrFunction || !f.IsInterface() || f.Style != "" || f.TemplatePath != "" {
+		return nil
+	}
+	addedFor := func(method string) string {
+		if method == "" {
+			return "for itself"
+		}
+		return "for " + method
+	}
+	helpers := f.helperNames()
+	added := map[string]string{}
+	add := func(owner string) error {
+		for _, name := range helpers[owner] {
+			if other, ok := added[name]; ok {
+				return fmt.Errorf("cannot generate a fake for %s because the %s the fake adds %s would clash with the one it adds %s", f.TargetName, name, addedFor(owner), addedFor(other))
+			}
+			added[name] = owner
+		}
+		return nil
+	}
+	if err := add(""); err != nil {
+		return err
+	}
+	for i := range f.Methods {
+		if err := add(f.Methods[i].Name); err != nil {
+			return err
+		}
+	}
+	for i := range f.Methods {
+		if owner, ok := added[f.Methods[i].Name]; ok {
+			return fmt.Errorf("cannot generate a fake for %s because its method %s would clash with the one the fake adds %s", f.TargetName, f.Methods[i].Name, addedFor(owner))
+		}
+	}
+	return nil
+}
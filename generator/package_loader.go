@@ -1,6 +1,9 @@
 package generator
 
 import (
+	"fmt"
+	"go/constant"
+	"go/token"
 	"go/types"
 
 	"golang.org/x/tools/go/packages"
@@ -40,3 +43,146 @@ func packageMethodSet(p *packages.Package) []*rawMethod {
 
 	return result
 }
+
+// WithPackageVars makes package mode also generate accessor methods for the
+// named exported variables and constants of the package, or for all of them
+// with "*": a getter named after each of them, and a setter named SetX for
+// each variable X. A variable whose type is shimmed with WithShimTypes has no
+// setter, as it can only hold the type itself and not its interface.
+func WithPackageVars(names ...string) Option {
+	return func(f *Fake) {
+		f.PackageVarNames = names
+	}
+}
+
+// WithoutPackageVars leaves the named variables and constants out of the
+// ones WithPackageVars generates accessors for.
+func WithoutPackageVars(names ...string) Option {
+	return func(f *Fake) {
+		f.ExcludedVarNames = names
+	}
+}
+
+// rawAccessor is the getter or the setter of a package-level variable or
+// constant.
+type rawAccessor struct {
+	Name      string
+	Var       string
+	Signature *types.Signature
+}
+
+// findPackageVars looks up the variables and constants named by
+// WithPackageVars in the target package. With "*", the ones that cannot be
+// accessed from another package are skipped instead of being an error.
+func (f *Fake) findPackageVars() error {
+	if len(f.PackageVarNames) == 0 {
+		return nil
+	}
+	if f.Mode != Package {
+		return fmt.Errorf("accessors for variables can only be generated in package mode")
+	}
+	excluded := map[string]bool{}
+	for _, name := range f.ExcludedVarNames {
+		excluded[name] = true
+	}
+	scope := f.Package.Types.Scope()
+	names, all := f.PackageVarNames, false
+	for _, name := range names {
+		if name == "*" {
+			names, all = scope.Names(), true
+			break
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] || excluded[name] {
+			continue
+		}
+		seen[name] = true
+		obj := scope.Lookup(name)
+		switch obj.(type) {
+		case *types.Var, *types.Const:
+		default:
+			obj = nil
+		}
+		if obj == nil || !obj.Exported() {
+			if all {
+				continue
+			}
+			return fmt.Errorf("cannot access %s: it is not an exported variable or constant of package %s", name, f.TargetPackage)
+		}
+		if typ := accessorType(obj); typ == nil || !isExportedType(typ) {
+			if all {
+				continue
+			}
+			return fmt.Errorf("cannot access %s: its type cannot be referred to from another package", name)
+		}
+		f.packageVars = append(f.packageVars, obj)
+	}
+
+	for _, obj := range f.packageVars {
+		if _, ok := obj.(*types.Var); !ok || f.shimFor(obj.Type()) != "" {
+			continue
+		}
+		setter := "Set" + obj.Name()
+		if clash := scope.Lookup(setter); clash != nil && (seen[setter] || isPackageFunc(clash)) {
+			return fmt.Errorf("cannot access %s: its setter %s clashes with %s of package %s", obj.Name(), setter, setter, f.TargetPackage)
+		}
+	}
+	return nil
+}
+
+func isPackageFunc(obj types.Object) bool {
+	_, ok := obj.(*types.Func)
+	return ok && obj.Exported()
+}
+
+// accessorType is the type the getter of a variable or constant returns. An
+// untyped constant gets its default type, or uint64 for an integer that only
+// fits in it; it is nil for one that does not fit either.
+func accessorType(obj types.Object) types.Type {
+	typ := obj.Type()
+	c, ok := obj.(*types.Const)
+	if !ok {
+		return typ
+	}
+	if basic, ok := typ.(*types.Basic); !ok || basic.Info()&types.IsUntyped == 0 {
+		return typ
+	}
+	typ = types.Default(typ)
+	if typ != types.Typ[types.Int] {
+		return typ
+	}
+	if _, exact := constant.Int64Val(c.Val()); exact {
+		return typ
+	}
+	if _, exact := constant.Uint64Val(c.Val()); exact {
+		return types.Typ[types.Uint64]
+	}
+	return nil
+}
+
+// packageVarAccessors are the getters and setters of the variables and
+// constants found by findPackageVars. The variables of a shimmed type only get
+// a getter.
+func (f *Fake) packageVarAccessors() []*rawAccessor {
+	var result []*rawAccessor
+	for _, obj := range f.packageVars {
+		typ := accessorType(obj)
+		value := types.NewTuple(types.NewVar(token.NoPos, obj.Pkg(), unexport(obj.Name()), typ))
+		result = append(result, &rawAccessor{
+			Name:      obj.Name(),
+			Var:       obj.Name(),
+			Signature: types.NewSignature(nil, nil, types.NewTuple(types.NewVar(token.NoPos, obj.Pkg(), "", typ)), false),
+		})
+		if _, ok := obj.(*types.Var); ok && f.shimFor(typ) == "" {
+			result = append(result, &rawAccessor{
+				Name:      "Set" + obj.Name(),
+				Var:       obj.Name(),
+				Signature: types.NewSignature(nil, value, nil, false),
+			})
+		}
+	}
+	return result
+}
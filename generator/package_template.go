@@ -21,7 +21,7 @@ import (
 	{{- end}}
 )
 
-//{{.Generate}} counterfeiter . {{.Name}}
+//{{Generate}} counterfeiter . {{.Name}}
 
 // {{.Name}} is a generated interface representing the exported functions
 // in the {{.TargetPackage}} package.
@@ -35,8 +35,56 @@ type {{.Name}}Shim struct {}
 
 {{- range .Methods}}
 func (p *{{.FakeName}}Shim) {{.Name}}({{.Params.AsNamedArgsWithTypes}}) {{.Returns.AsReturnSignature}} {
+  {{- if and .Var .Params.HasLength}}
+  {{.FakePackage}}.{{.Var}} = {{.Params.WithPrefix ""}}
+  {{- else if and .Var .Returns.HasShims}}
+  {{.Returns.WithPrefix ""}} := {{.FakePackage}}.{{.Var}}
+  return {{.Returns.AsShimmedResults}}
+  {{- else if .Var}}
+  return {{.FakePackage}}.{{.Var}}
+  {{- else if .Returns.HasShims}}
+  {{.Returns.WithPrefix ""}} := {{.FakePackage}}.{{.Name}}({{.Params.AsNamedArgsForInvocation}})
+  return {{.Returns.AsShimmedResults}}
+  {{- else}}
   {{if .Returns.HasLength}}return {{end}}{{.FakePackage}}.{{.Name}}({{.Params.AsNamedArgsForInvocation}})
+  {{- end}}
+}
+{{end}}
+var _ {{.Name}} = new({{.Name}}Shim)
+{{range .ShimTypes}}
+//{{Generate}} counterfeiter . {{.Name}}
+
+// {{.Name}} is a generated interface representing the exported methods
+// of {{.Type}}.
+type {{.Name}} interface {
+  {{- range .Methods}}
+  {{.Name}}({{.Params.AsNamedArgsWithTypes}}) {{.Returns.AsReturnSignature}}
+  {{- end}}
+}
+
+// {{.Name}}Shim implements {{.Name}} by calling the methods of Delegate.
+type {{.Name}}Shim struct {
+  Delegate {{.Type}}
+}
+
+// New{{.Name}}Shim returns a {{.Name}} that calls the methods of delegate,
+// or nil if delegate is nil.
+func New{{.Name}}Shim(delegate {{.Type}}) {{.Name}} {
+  if delegate == nil {
+    return nil
+  }
+  return &{{.Name}}Shim{Delegate: delegate}
+}
+
+{{- range .Methods}}
+func (shim *{{.FakeName}}Shim) {{.Name}}({{.Params.AsNamedArgsWithTypes}}) {{.Returns.AsReturnSignature}} {
+  {{- if .Returns.HasShims}}
+  {{.Returns.WithPrefix ""}} := shim.Delegate.{{.Name}}({{.Params.AsNamedArgsForInvocation}})
+  return {{.Returns.AsShimmedResults}}
+  {{- else}}
+  {{if .Returns.HasLength}}return {{end}}shim.Delegate.{{.Name}}({{.Params.AsNamedArgsForInvocation}})
+  {{- end}}
 }
 {{end}}
 var _ {{.Name}} = new({{.Name}}Shim)
-`
+{{end}}`
@@ -2,19 +2,34 @@ package generator
 
 import "strings"
 
+// Params is a slice of Param.
 type Params []Param
 
+// Param is a parameter of a method.
 type Param struct {
 	Name       string
 	Type       string
 	IsVariadic bool
 	IsSlice    bool
+	DeepCopy   bool // recorded as a deep copy, made by the deepCopy helper
 }
 
+// Slices are the slice parameters that are recorded as a copy of the slice.
 func (p Params) Slices() Params {
 	var result Params
 	for i := range p {
-		if p[i].IsSlice {
+		if p[i].IsSlice && !p[i].DeepCopy {
+			result = append(result, p[i])
+		}
+	}
+	return result
+}
+
+// DeepCopies are the parameters that are recorded as a deep copy.
+func (p Params) DeepCopies() Params {
+	var result Params
+	for i := range p {
+		if p[i].DeepCopy {
 			result = append(result, p[i])
 		}
 	}
@@ -65,6 +80,20 @@ func (p Params) AsNamedArgsWithTypes() string {
 	return strings.Join(params, ", ")
 }
 
+// AsNamedArgsWithSliceTypes is like AsNamedArgsWithTypes, but a variadic
+// parameter is declared as a slice so that other parameters may follow it.
+func (p Params) AsNamedArgsWithSliceTypes() string {
+	if len(p) == 0 {
+		return ""
+	}
+
+	params := []string{}
+	for i := range p {
+		params = append(params, unexport(p[i].Name)+" "+strings.Replace(p[i].Type, "...", "[]", -1))
+	}
+	return strings.Join(params, ", ")
+}
+
 func (p Params) AsNamedArgs() string {
 	if len(p) == 0 {
 		return ""
@@ -72,7 +101,7 @@ func (p Params) AsNamedArgs() string {
 
 	params := []string{}
 	for i := range p {
-		if p[i].IsSlice {
+		if p[i].IsSlice || p[i].DeepCopy {
 			params = append(params, unexport(p[i].Name)+"Copy")
 		} else {
 			params = append(params, unexport(p[i].Name))
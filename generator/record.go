@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithRecordCalls makes the fake of an interface keep a record of each call
+// to its methods, with the results of the call, when it started, how long it
+// took and the value it panicked with, which XCallRecords and XResultsForCall
+// return.
+func WithRecordCalls() Option {
+	return func(f *Fake) {
+		f.RecordCalls = true
+	}
+}
+
+// RecordsCalls is true if the fake keeps a record of each call.
+func (f *Fake) RecordsCalls() bool {
+	return f.RecordCalls && f.Mode == InterfaceOrFunction && f.IsInterface()
+}
+
+// NamesResults is true if the fake of the method has to name its results,
+// so that they can be recorded, or set before it returns early.
+func (f *Fake) NamesResults(m Method) bool {
+	if !m.Returns.HasLength() {
+		return false
+	}
+	return f.RecordsCalls() || f.TakesContext() && m.TakesContext || f.FailsAll() && m.ReturnsError
+}
+
+// CallRecordFields are the fields of the record the fake of an interface keeps
+// of each call to the method, for its arguments and results. They are named
+// after their position, ArgN and ResultN, so that they never clash with the
+// fields for the timing and the panic of the call.
+func (m Method) CallRecordFields() string {
+	fields := []string{}
+	for i := range m.Params {
+		fields = append(fields, fmt.Sprintf("Arg%d %s", i+1, strings.Replace(m.Params[i].Type, "...", "[]", -1)))
+	}
+	for i := range m.Returns {
+		fields = append(fields, fmt.Sprintf("Result%d %s", i+1, m.Returns[i].Type))
+	}
+	return strings.Join(fields, "\n\t")
+}
+
+// CallRecordArgs is the list of fields of a record literal that sets each
+// argument field to the argument as it is recorded by XArgsForCall, such as
+// Arg1: arg1.
+func (m Method) CallRecordArgs() string {
+	params := strings.Split(m.Params.AsNamedArgs(), ", ")
+	args := []string{}
+	for i := range m.Params {
+		args = append(args, fmt.Sprintf("Arg%d: %s", i+1, params[i]))
+	}
+	return strings.Join(args, ", ")
+}
+
+// CallRecordResults is the list of the result fields of a record, each with
+// the given prefix, such as record.Result1.
+func (m Method) CallRecordResults(prefix string) string {
+	results := []string{}
+	for i := range m.Returns {
+		results = append(results, fmt.Sprintf("%sResult%d", prefix, i+1))
+	}
+	return strings.Join(results, ", ")
+}
@@ -0,0 +1,24 @@
+package generator
+
+// WithCallRecorder adds SetCallRecorder to the fake of an interface or
+// function, which attaches it to a recorder that is passed every call, such
+// as a callorder.Sequencer.
+func WithCallRecorder() Option {
+	return func(f *Fake) {
+		f.CallRecorder = true
+	}
+}
+
+// HasCallRecorder is true if the fake can be attached to a call recorder.
+func (f *Fake) HasCallRecorder() bool {
+	return f.CallRecorder && f.Mode == InterfaceOrFunction && (f.IsInterface() || f.IsFunction())
+}
+
+// callRecorderTemplate defines the type of the call recorders that the fakes
+// of interfaces and functions accept, which has the method set of
+// callorder.Recorder and is the parameter type of callorder.Fake.
+const callRecorderTemplate string = `{{define "callRecorder" -}}
+interface {
+	RecordCall(interface{}, string, []interface{})
+}
+{{- end}}`
@@ -0,0 +1,17 @@
+package generator
+
+// WithReset adds Reset and ResetCalls to the fake of an interface or function,
+// which clear everything it recorded and was stubbed with, or only the calls,
+// and XReset to the fake of an interface, which clears a single method.
+func WithReset() Option {
+	return func(f *Fake) {
+		f.Reset = true
+	}
+}
+
+// HasReset is true if the fake can be cleared to be used again. The fake of
+// an interface that declares Reset leaves out Reset and ResetCalls, but keeps
+// XReset.
+func (f *Fake) HasReset() bool {
+	return f.Reset && f.Mode == InterfaceOrFunction && (f.IsInterface() || f.IsFunction())
+}
@@ -11,6 +11,7 @@ type Returns []Return
 type Return struct {
 	Name string
 	Type string
+	Shim string // the shim type the result is wrapped in, in package mode
 }
 
 // HasLength is true if there are returns, else false.
@@ -70,6 +71,20 @@ func (r Returns) AsNamedArgs() string {
 	return strings.Join(rets, ", ")
 }
 
+// AsKeyedArgs is the list of fields of a struct literal that sets each result
+// field to the variable of the same name, such as result1: result1.
+func (r Returns) AsKeyedArgs() string {
+	if len(r) == 0 {
+		return ""
+	}
+
+	rets := []string{}
+	for i := range r {
+		rets = append(rets, unexport(r[i].Name)+": "+unexport(r[i].Name))
+	}
+	return strings.Join(rets, ", ")
+}
+
 func (r Returns) AsReturnSignature() string {
 	if len(r) == 0 {
 		return ""
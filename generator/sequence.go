@@ -0,0 +1,50 @@
+package generator
+
+// WithReturnsSequence adds XReturnsSequence to the fake of an interface or
+// function for its methods with results, which builds a sequence of results
+// to return from the calls that follow.
+func WithReturnsSequence() Option {
+	return func(f *Fake) {
+		f.ReturnsSequence = true
+	}
+}
+
+// HasReturnsSequence is true if the results of the fake can be stubbed with a
+// sequence.
+func (f *Fake) HasReturnsSequence() bool {
+	return f.ReturnsSequence && f.Mode == InterfaceOrFunction && (f.IsInterface() || f.IsFunction())
+}
+
+// ReturnsSequences is true if the fake has sequences and methods with
+// results, which get an XReturnsSequence builder and so need the sequence
+// policies.
+func (f *Fake) ReturnsSequences() bool {
+	if !f.HasReturnsSequence() {
+		return false
+	}
+	if f.IsFunction() {
+		return f.Function.Returns.HasLength()
+	}
+	for i := range f.Methods {
+		if f.Methods[i].Returns.HasLength() {
+			return true
+		}
+	}
+	return false
+}
+
+// sequencePolicyTemplate defines what the sequences of the interface and
+// function templates return once their results run out. A sequence is built
+// with Then, one call per tuple of results, since Go has no way to pass a
+// list of result tuples of mixed types to XReturnsSequence itself, and it is
+// ended with RepeatLast, Cycle, ZeroValues or Fail, which set its policy.
+const sequencePolicyTemplate string = `{{define "sequencePolicy" -}}
+type {{UnExport .Name}}SequencePolicy int
+
+const (
+	{{UnExport .Name}}RepeatLast {{UnExport .Name}}SequencePolicy = iota
+	{{UnExport .Name}}Cycle
+	{{UnExport .Name}}ZeroValues
+	{{UnExport .Name}}Fail
+)
+{{- end}}`
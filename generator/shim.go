@@ -0,0 +1,171 @@
+package generator
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// ShimType is an exported type of the target package that package mode
+// generates an interface and a delegating shim for, alongside the interface
+// for the package-level functions.
+type ShimType struct {
+	Name    string // the name of the type, which the interface is also given
+	Type    string // the type the shim delegates to, such as *os.File
+	Methods []Method
+	obj     *types.TypeName
+}
+
+// WithShimTypes makes package mode also generate an interface and a shim for
+// each of the named exported struct types of the package. Functions and
+// methods returning a pointer to one of these types return its interface
+// instead.
+func WithShimTypes(names ...string) Option {
+	return func(f *Fake) {
+		f.ShimTypeNames = names
+	}
+}
+
+// findShimTypes looks up the types named by WithShimTypes in the target
+// package.
+func (f *Fake) findShimTypes() error {
+	if len(f.ShimTypeNames) == 0 {
+		return nil
+	}
+	if f.Mode != Package {
+		return fmt.Errorf("shim types can only be generated in package mode")
+	}
+	seen := map[string]bool{}
+	for _, name := range f.ShimTypeNames {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		obj, ok := f.Package.Types.Scope().Lookup(name).(*types.TypeName)
+		if !ok || !obj.Exported() {
+			return fmt.Errorf("cannot shim %s: it is not an exported type of package %s", name, f.TargetPackage)
+		}
+		if _, ok := obj.Type().Underlying().(*types.Struct); !ok {
+			return fmt.Errorf("cannot shim %s: it is not a struct type", name)
+		}
+		if name == f.Name {
+			return fmt.Errorf("cannot shim %s: it has the same name as the interface for the package", name)
+		}
+		f.ShimTypes = append(f.ShimTypes, ShimType{Name: name, obj: obj})
+	}
+	return nil
+}
+
+// typeMethodSet identifies the exported methods of the named type, including
+// the ones with a pointer receiver. Methods whose signatures refer to
+// unexported types are skipped, as another package could not declare them.
+func typeMethodSet(t types.Type) []*rawMethod {
+	var result []*rawMethod
+	for _, m := range interfaceMethodSet(t) {
+		if m.Func.Exported() && isExportedSignature(m.Signature) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+func (f *Fake) loadShimTypes(importsMap map[string]Import) {
+	for i := range f.ShimTypes {
+		shim := &f.ShimTypes[i]
+		shim.Type = typeFor(types.NewPointer(shim.obj.Type()), importsMap)
+		for _, m := range typeMethodSet(shim.obj.Type()) {
+			method := methodForSignature(m.Signature, shim.Name, f.TargetAlias, m.Func.Name(), importsMap, f.UseParamNames, false)
+			f.shimResults(&method, m.Signature)
+			shim.Methods = append(shim.Methods, method)
+		}
+	}
+}
+
+// shimResults makes the results of method that are pointers to a shim type
+// return the interface of the shim instead.
+func (f *Fake) shimResults(method *Method, sig *types.Signature) {
+	for i := 0; i < sig.Results().Len(); i++ {
+		if shim := f.shimFor(sig.Results().At(i).Type()); shim != "" {
+			method.Returns[i].Type = shim
+			method.Returns[i].Shim = shim
+		}
+	}
+}
+
+// shimFor is the name of the shim type that typ is a pointer to, or the empty
+// string if it is not one.
+func (f *Fake) shimFor(typ types.Type) string {
+	ptr, ok := typ.(*types.Pointer)
+	if !ok {
+		return ""
+	}
+	named, ok := ptr.Elem().(*types.Named)
+	if !ok {
+		return ""
+	}
+	for i := range f.ShimTypes {
+		if named.Obj() == f.ShimTypes[i].obj {
+			return f.ShimTypes[i].Name
+		}
+	}
+	return ""
+}
+
+func isExportedSignature(sig *types.Signature) bool {
+	for i := 0; i < sig.Params().Len(); i++ {
+		if !isExportedType(sig.Params().At(i).Type()) {
+			return false
+		}
+	}
+	for i := 0; i < sig.Results().Len(); i++ {
+		if !isExportedType(sig.Results().At(i).Type()) {
+			return false
+		}
+	}
+	return true
+}
+
+// isExportedType is true if typ can be referred to from another package.
+func isExportedType(typ types.Type) bool {
+	switch t := typ.(type) {
+	case *types.Named:
+		return t.Obj().Pkg() == nil || t.Obj().Exported()
+	case *types.Pointer:
+		return isExportedType(t.Elem())
+	case *types.Slice:
+		return isExportedType(t.Elem())
+	case *types.Array:
+		return isExportedType(t.Elem())
+	case *types.Chan:
+		return isExportedType(t.Elem())
+	case *types.Map:
+		return isExportedType(t.Key()) && isExportedType(t.Elem())
+	case *types.Signature:
+		return isExportedSignature(t)
+	}
+	return true
+}
+
+// HasShims is true if any of the results is the interface of a shim type.
+func (r Returns) HasShims() bool {
+	for i := range r {
+		if r[i].Shim != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// AsShimmedResults is the list of results returned by a shim, where the
+// results that are pointers to a shim type are wrapped in their shim.
+func (r Returns) AsShimmedResults() string {
+	rets := []string{}
+	for i := range r {
+		if r[i].Shim != "" {
+			rets = append(rets, "New"+r[i].Shim+"Shim("+unexport(r[i].Name)+")")
+		} else {
+			rets = append(rets, unexport(r[i].Name))
+		}
+	}
+	return strings.Join(rets, ", ")
+}
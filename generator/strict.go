@@ -0,0 +1,53 @@
+package generator
+
+// WithStrict makes the fake fail when a method is called while nothing is
+// stubbed for it, instead of returning zero values or doing nothing.
+func WithStrict() Option {
+	return func(f *Fake) {
+		f.Strict = true
+	}
+}
+
+// IsStrict is true if the fake fails calls that nothing is stubbed for.
+func (f *Fake) IsStrict() bool {
+	return f.Strict && (f.IsInterface() || f.IsFunction())
+}
+
+// strictTemplate defines the methods of a strict fake that decide what
+// happens to a call that nothing is stubbed for.
+const strictTemplate string = `{{define "strict" -}}
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) SetTB(tb interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	fake.testingTB = tb
+}
+
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) AllowUnstubbed(methods ...string) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.allowedUnstubbed == nil {
+		fake.allowedUnstubbed = map[string]bool{}
+	}
+	for _, method := range methods {
+		fake.allowedUnstubbed[method] = true
+	}
+}
+
+func (fake *{{.Name}}{{.TypeParams.AsArgs}}) failUnstubbed(method string, args []interface{}) {
+	fake.invocationsMutex.RLock()
+	tb, allowed := fake.testingTB, fake.allowedUnstubbed[method]
+	fake.invocationsMutex.RUnlock()
+	if allowed {
+		return
+	}
+	message := fmt.Sprintf("{{.Name}}.%s was called with %v, but nothing is stubbed for it", method, args)
+	if tb == nil {
+		panic(message)
+	}
+	tb.Helper()
+	tb.Fatalf("%s", message)
+}
+{{- end}}`
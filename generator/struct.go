@@ -0,0 +1,34 @@
+package generator
+
+import (
+	"go/types"
+)
+
+// WithAdapter makes struct mode also generate an adapter, which implements
+// the extracted interface by calling the methods of a delegate.
+func WithAdapter() Option {
+	return func(f *Fake) {
+		f.Adapter = true
+	}
+}
+
+// IsStruct indicates whether the target is a struct type, which struct mode
+// extracts an interface from.
+func (f *Fake) IsStruct() bool {
+	if f.TargetType == nil || f.TargetType.Underlying() == nil {
+		return false
+	}
+	_, ok := f.TargetType.Underlying().(*types.Struct)
+	return ok
+}
+
+// AdapterName is the name of the adapter generated in struct mode.
+func (f *Fake) AdapterName() string {
+	return f.Name + "Adapter"
+}
+
+// StructType is the type that implements the interface extracted in struct
+// mode, such as *sdk.Client.
+func (f *Fake) StructType() string {
+	return "*" + f.TargetAlias + "." + f.TargetName + f.TargetTypeArgs
+}
@@ -0,0 +1,40 @@
+package generator
+
+const structTemplate string = `// Code generated by counterfeiter. DO NOT EDIT.
+package {{.DestinationPackage}}
+
+import (
+	{{- range .Imports}}
+	{{.Alias}} "{{.Path}}"
+	{{- end}}
+)
+
+// {{.Name}} is a generated interface representing the exported methods
+// of {{.StructType}}.
+type {{.Name}}{{.TypeParams.AsDecl}} interface {
+  {{- range .Methods}}
+  {{.Name}}({{.Params.AsNamedArgsWithTypes}}) {{.Returns.AsReturnSignature}}
+  {{- end}}
+}
+{{if not .TypeParams.HasLength}}
+var _ {{.Name}} = ({{.StructType}})(nil)
+{{end}}
+{{- if .Adapter}}
+// {{.AdapterName}} implements {{.Name}} by calling the methods of Delegate.
+type {{.AdapterName}}{{.TypeParams.AsDecl}} struct {
+  Delegate {{.StructType}}
+}
+
+// New{{.AdapterName}} returns a {{.Name}} that calls the methods of delegate.
+func New{{.AdapterName}}{{.TypeParams.AsDecl}}(delegate {{.StructType}}) {{.Name}}{{.TypeParams.AsArgs}} {
+  return &{{.AdapterName}}{{.TypeParams.AsArgs}}{Delegate: delegate}
+}
+{{range .Methods}}
+func (adapter *{{$.AdapterName}}{{$.TypeParams.AsArgs}}) {{.Name}}({{.Params.AsNamedArgsWithTypes}}) {{.Returns.AsReturnSignature}} {
+  {{if .Returns.HasLength}}return {{end}}adapter.Delegate.{{.Name}}({{.Params.AsNamedArgsForInvocation}})
+}
+{{end}}
+{{- if not .TypeParams.HasLength}}
+var _ {{.Name}} = new({{.AdapterName}})
+{{- end}}
+{{end}}`
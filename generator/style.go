@@ -0,0 +1,74 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithStyle makes the fake of an interface a test double in another style
+// than counterfeiter's: "testify" embeds the mock.Mock of testify, and
+// "gomock" is driven by a gomock.Controller, like the doubles of mockgen.
+func WithStyle(style string) Option {
+	return func(f *Fake) {
+		f.Style = style
+	}
+}
+
+// addStyleImports adds the imports of the test doubles of the style of the
+// fake, instead of the ones of a counterfeiter fake.
+func (f *Fake) addStyleImports() {
+	switch f.Style {
+	case "testify":
+		f.AddImport("mock", "github.com/stretchr/testify/mock")
+	case "gomock":
+		f.AddImport("gomock", "go.uber.org/mock/gomock")
+		f.AddImport("reflect", "reflect")
+	}
+}
+
+// styleTemplate is the template for the test double of the style of the
+// fake.
+func (f *Fake) styleTemplate() (string, error) {
+	if f.Mode != InterfaceOrFunction || !f.IsInterface() {
+		return "", fmt.Errorf("a %s double can only be generated for an interface", f.Style)
+	}
+	switch f.Style {
+	case "testify":
+		return testifyTemplate, nil
+	case "gomock":
+		return gomockTemplate, nil
+	}
+	return "", fmt.Errorf("unknown style %q, which must be testify or gomock", f.Style)
+}
+
+// IsVariadic is true if the last parameter of the method is variadic.
+func (m Method) IsVariadic() bool {
+	return len(m.Params) > 0 && m.Params[len(m.Params)-1].IsVariadic
+}
+
+// FixedParams are the parameters of the method before a variadic one.
+func (m Method) FixedParams() Params {
+	if m.IsVariadic() {
+		return m.Params[:len(m.Params)-1]
+	}
+	return m.Params
+}
+
+// VariadicParam is the name of the variadic parameter of the method.
+func (m Method) VariadicParam() string {
+	return unexport(m.Params[len(m.Params)-1].Name)
+}
+
+// RecorderParams is the parameter list of the method of a gomock recorder,
+// which takes a matcher or a value for each parameter of the method.
+func (m Method) RecorderParams() string {
+	params := []string{}
+	for i := range m.Params {
+		if m.Params[i].IsVariadic {
+			params = append(params, unexport(m.Params[i].Name)+" ...interface{}")
+		} else {
+			params = append(params, unexport(m.Params[i].Name)+" interface{}")
+		}
+	}
+	return strings.Join(params, ", ")
+}
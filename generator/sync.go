@@ -0,0 +1,16 @@
+package generator
+
+// WithSyncHooks adds the hooks for testing concurrent callers to the fake of
+// an interface or a function: XBlockUntil holds the calls to a method until
+// they are released, XWaitForCalls waits for them to be made, and
+// XCallEvents reports each of them on a channel.
+func WithSyncHooks() Option {
+	return func(f *Fake) {
+		f.SyncHooks = true
+	}
+}
+
+// HasSyncHooks is true if the fake has the hooks for concurrent callers.
+func (f *Fake) HasSyncHooks() bool {
+	return f.SyncHooks && f.Mode == InterfaceOrFunction && (f.IsInterface() || f.IsFunction())
+}
@@ -0,0 +1,102 @@
+package generator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// TemplateFuncs are the functions that custom templates can call, besides the
+// ones built into text/template:
+//
+//	ToLower     lower cases a string, as strings.ToLower
+//	UnExport    lower cases the first letter of an identifier
+//	Export      upper cases the first letter of an identifier
+//	IsExported  is true if an identifier starts with an upper case letter
+//	Replace     replaces n occurrences of old by new in s, as strings.Replace
+//	Generate    is "go:generate", to write a directive without go generate
+//	            finding it in the template itself
+//
+// Functions are only ever added, so that custom templates keep working.
+var TemplateFuncs = template.FuncMap{
+	"ToLower":    strings.ToLower,
+	"UnExport":   unexport,
+	"Export":     export,
+	"IsExported": isExported,
+	"Replace":    strings.Replace,
+	"Generate":   func() string { return "go:generate" },
+}
+
+// WithTemplate makes the fake be generated with the template at path instead
+// of the built-in one. The path is either a template file, or a directory
+// that holds an interface.tmpl, function.tmpl or package.tmpl file for each
+// kind of fake to customize; the other kinds keep the built-in template.
+//
+// A custom template is executed with the *Fake, and can call TemplateFuncs.
+// It can also include the built-in template for its kind, which is defined
+// as "interface", "function" or "package", for instance to add constructors
+// after it:
+//
+//	{{template "interface" .}}
+//
+//	func New{{.Name}}() *{{.Name}} { ... }
+func WithTemplate(path string) Option {
+	return func(f *Fake) {
+		f.TemplatePath = path
+	}
+}
+
+// kind is the name of the kind of fake, which its built-in template is
+// defined as and its file in a directory of custom templates is named after.
+func (f *Fake) kind() string {
+	switch {
+	case f.Mode == Package:
+		return "package"
+	case f.Mode == Struct:
+		return "struct"
+	case f.IsInterface():
+		return "interface"
+	case f.IsFunction():
+		return "function"
+	}
+	return ""
+}
+
+// customTemplate returns the template at TemplatePath for the fake, with the
+// built-in one it replaces defined under the name of its kind. It returns
+// nil when the directory at TemplatePath has no template for the kind.
+func (f *Fake) customTemplate(builtin *template.Template) (*template.Template, error) {
+	path := f.TemplatePath
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		path = filepath.Join(path, f.kind()+".tmpl")
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := template.New(filepath.Base(path)).Funcs(TemplateFuncs)
+	for _, t := range builtin.Templates() {
+		name := t.Name()
+		if name == builtin.Name() {
+			name = f.kind()
+		}
+		if _, err := tmpl.AddParseTree(name, t.Tree); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tmpl.Parse(string(text)); err != nil {
+		return nil, fmt.Errorf("cannot parse the template %s: %v", path, err)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,56 @@
+package generator
+
+// testifyTemplate generates a double of an interface that embeds the
+// mock.Mock of testify, so that calls are stubbed with On and checked with
+// AssertExpectations.
+const testifyTemplate string = `// Code generated by counterfeiter. DO NOT EDIT.
+package {{.DestinationPackage}}
+
+import (
+	{{- range .Imports}}
+	{{.Alias}} "{{.Path}}"
+	{{- end}}
+)
+
+type {{.Name}}{{.TypeParams.AsDecl}} struct {
+	mock.Mock
+}
+
+func New{{.Name}}{{.TypeParams.AsDecl}}(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *{{.Name}}{{.TypeParams.AsArgs}} {
+	fake := new({{.Name}}{{.TypeParams.AsArgs}})
+	fake.Mock.Test(t)
+	t.Cleanup(func() {
+		fake.AssertExpectations(t)
+	})
+	return fake
+}
+
+{{range .Methods -}}
+func (fake *{{.FakeName}}{{$.TypeParams.AsArgs}}) {{.Name}}({{.Params.AsNamedArgsWithTypes}}) {{if .Returns.HasLength}}({{.Returns.AsNamedArgsWithTypes}}){{end}} {
+	{{- if .Returns.HasLength}}
+	args := fake.Called({{.Params.WithPrefix ""}})
+	{{- range $i, $ret := .Returns}}
+	if value := args.Get({{$i}}); value != nil {
+		{{UnExport $ret.Name}} = value.({{$ret.Type}})
+	}
+	{{- end}}
+	return {{.Returns.WithPrefix ""}}
+	{{- else}}
+	fake.Called({{.Params.WithPrefix ""}})
+	{{- end}}
+}
+
+{{end -}}
+{{if IsExported .TargetName -}}
+{{if .TypeParams.HasLength -}}
+func _{{.TypeParams.AsDecl}}() {
+	var _ {{.TargetAlias}}.{{.TargetName}}{{.TargetTypeArgs}} = new({{.Name}}{{.TypeParams.AsArgs}})
+}
+{{- else -}}
+var _ {{.TargetAlias}}.{{.TargetName}}{{.TargetTypeArgs}} = new({{.Name}})
+{{- end}}
+{{- end}}
+`
@@ -0,0 +1,170 @@
+package generator
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// TypeParams is the type parameter list of a generic fake.
+type TypeParams []TypeParam
+
+// TypeParam is a type parameter of a generic fake, with its constraint.
+type TypeParam struct {
+	Name       string
+	Constraint string
+}
+
+// HasLength is true if there are type parameters, else false.
+func (p TypeParams) HasLength() bool {
+	return len(p) > 0
+}
+
+// AsDecl is the type parameter list used to declare a generic fake, such as
+// [T any, K comparable].
+func (p TypeParams) AsDecl() string {
+	if len(p) == 0 {
+		return ""
+	}
+
+	params := []string{}
+	for i := range p {
+		params = append(params, p[i].Name+" "+p[i].Constraint)
+	}
+	return "[" + strings.Join(params, ", ") + "]"
+}
+
+// AsArgs is the type argument list used to refer to a generic fake from its
+// own methods, such as [T, K].
+func (p TypeParams) AsArgs() string {
+	if len(p) == 0 {
+		return ""
+	}
+
+	params := []string{}
+	for i := range p {
+		params = append(params, p[i].Name)
+	}
+	return "[" + strings.Join(params, ", ") + "]"
+}
+
+// SplitTypeArgs splits the type arguments of an instantiated generic type,
+// such as Repository[User], from its name.
+func SplitTypeArgs(name string) (string, string) {
+	i := strings.Index(name, "[")
+	if i < 0 {
+		return name, ""
+	}
+	return name[:i], name[i:]
+}
+
+// instantiate evaluates expr, an instance of a generic type such as
+// Repository[User], in the scope of the files of pkg so that the type arguments
+// may refer to the packages those files import.
+func instantiate(pkg *packages.Package, expr string) (types.Type, error) {
+	err := fmt.Errorf("cannot instantiate %s: package %s has no source files", expr, pkg.PkgPath)
+	for i := range pkg.Syntax {
+		var tv types.TypeAndValue
+		tv, err = types.Eval(pkg.Fset, pkg.Types, pkg.Syntax[i].Name.Pos(), expr)
+		if err != nil {
+			continue
+		}
+		if !tv.IsType() {
+			return nil, fmt.Errorf("cannot instantiate %s: it is not a type", expr)
+		}
+		return tv.Type, nil
+	}
+	return nil, err
+}
+
+// addImportsForTypeArgs adds the imports needed by the type arguments of an
+// instance of a generic type.
+func (f *Fake) addImportsForTypeArgs(t *types.Named) {
+	args := t.TypeArgs()
+	for i := 0; i < args.Len(); i++ {
+		f.addImportsFor(args.At(i))
+	}
+}
+
+// addImportsForEmbedded adds the imports needed by the types embedded in an
+// interface, such as the unions of a constraint.
+func (f *Fake) addImportsForEmbedded(t *types.Interface) {
+	for i := 0; i < t.NumEmbeddeds(); i++ {
+		f.addImportsFor(t.EmbeddedType(i))
+	}
+}
+
+// addImportsForGeneric adds the imports needed by the types that only appear
+// in generic code, and reports whether typ was one of them.
+func (f *Fake) addImportsForGeneric(typ types.Type) bool {
+	switch t := typ.(type) {
+	case *types.TypeParam:
+		// The constraint is imported along with the type parameter list.
+		return true
+	case *types.Union:
+		for i := 0; i < t.Len(); i++ {
+			f.addImportsFor(t.Term(i).Type())
+		}
+		return true
+	}
+	return false
+}
+
+// targetTypeParams returns the type parameters of the target when it is a
+// generic type that has not been instantiated.
+func (f *Fake) targetTypeParams() *types.TypeParamList {
+	t, ok := f.TargetType.(*types.Named)
+	if !ok || t.TypeArgs().Len() > 0 {
+		return nil
+	}
+	return t.TypeParams()
+}
+
+// addImportsForTypeParams adds the imports needed by the constraints of the
+// type parameters of a generic target.
+func (f *Fake) addImportsForTypeParams() {
+	tparams := f.targetTypeParams()
+	for i := 0; i < tparams.Len(); i++ {
+		f.addImportsFor(tparams.At(i).Constraint())
+	}
+}
+
+// loadTypeParams sets the type parameters of the fake of a generic target,
+// and the type arguments used to refer to the target.
+func (f *Fake) loadTypeParams() {
+	importsMap := f.importsMap()
+	tparams := f.targetTypeParams()
+	for i := 0; i < tparams.Len(); i++ {
+		f.TypeParams = append(f.TypeParams, TypeParam{
+			Name:       tparams.At(i).Obj().Name(),
+			Constraint: typeFor(tparams.At(i).Constraint(), importsMap),
+		})
+	}
+	if f.TypeParams.HasLength() {
+		f.TargetTypeArgs = f.TypeParams.AsArgs()
+		return
+	}
+
+	t, ok := f.TargetType.(*types.Named)
+	if !ok || t.TypeArgs().Len() == 0 {
+		return
+	}
+	args := []string{}
+	for i := 0; i < t.TypeArgs().Len(); i++ {
+		args = append(args, typeFor(t.TypeArgs().At(i), importsMap))
+	}
+	f.TargetTypeArgs = "[" + strings.Join(args, ", ") + "]"
+}
+
+func isTypeParam(t types.Type) bool {
+	_, ok := t.(*types.TypeParam)
+	return ok
+}
+
+// isConstraint is true if t can only be used as a type constraint, since its
+// type set is not just a set of methods.
+func isConstraint(t *types.Interface) bool {
+	return !t.IsMethodSet()
+}
@@ -1,18 +1,30 @@
 module github.com/maxbrunsfeld/counterfeiter
 
+go 1.25.0
+
+require (
+	github.com/onsi/gomega v1.4.2-0.20180831124310-ae19f1b56d53
+	github.com/sclevine/spec v1.1.0
+	github.com/stretchr/testify v1.9.0
+	go.uber.org/mock v0.6.0
+	golang.org/x/tools v0.44.0
+	gopkg.in/yaml.v2 v2.2.1
+)
+
 require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/fsnotify/fsnotify v1.4.7 // indirect
 	github.com/golang/protobuf v1.2.0 // indirect
 	github.com/hpcloud/tail v1.0.0 // indirect
 	github.com/onsi/ginkgo v1.6.0 // indirect
-	github.com/onsi/gomega v1.4.2-0.20180831124310-ae19f1b56d53
-	github.com/sclevine/spec v1.1.0
-	golang.org/x/net v0.0.0-20180911220305-26e67e76b6c3 // indirect
-	golang.org/x/sync v0.0.0-20180314180146-1d60e4601c6f // indirect
-	golang.org/x/sys v0.0.0-20180909124046-d0be0721c37e // indirect
-	golang.org/x/text v0.3.0 // indirect
-	golang.org/x/tools v0.0.0-20181024171208-a2dc47679d30
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/stretchr/objx v0.5.2 // indirect
+	golang.org/x/mod v0.36.0 // indirect
+	golang.org/x/net v0.53.0 // indirect
+	golang.org/x/sync v0.20.0 // indirect
+	golang.org/x/sys v0.43.0 // indirect
+	golang.org/x/text v0.36.0 // indirect
 	gopkg.in/fsnotify.v1 v1.4.7 // indirect
 	gopkg.in/tomb.v1 v1.0.0-20141024135613-dd632973f1e7 // indirect
-	gopkg.in/yaml.v2 v2.2.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
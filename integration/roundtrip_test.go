@@ -143,6 +143,94 @@ func runTests(useGopath bool, t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("generating an interface for a package with shims for its types", func() {
+		it("succeeds", func() {
+			initModuleFunc()
+			f, err := generator.NewFake(generator.Package, "", "os", "Os", "custom", baseDir, generator.WithShimTypes("File", "Process"))
+			Expect(err).NotTo(HaveOccurred())
+			b, err := f.Generate(true) // Flip to false to see output if goimports fails
+			Expect(err).NotTo(HaveOccurred())
+			if writeToTestData {
+				WriteOutput(b, filepath.Join("testdata", "output", "package_mode_shims", "actual.go"))
+			}
+			Expect(string(b)).To(ContainSubstring("Open(arg1 string) (File, error)"))
+			Expect(string(b)).To(ContainSubstring("func NewFileShim(delegate *os.File) File {"))
+			Expect(string(b)).To(ContainSubstring("FindProcess(arg1 int) (Process, error)"))
+			WriteOutput(b, filepath.Join(baseDir, "fixturesfakes", "fake_os.go"))
+			RunBuild(baseDir)
+		})
+	})
+
+	when("generating a fake with the parameter names from the source", func() {
+		it("succeeds", func() {
+			initModuleFunc()
+			copyFileFunc("named_params.go")
+			f, err := generator.NewFake(generator.InterfaceOrFunction, "NamedParams", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeNamedParams", "fixturesfakes", baseDir, generator.WithParamNames())
+			Expect(err).NotTo(HaveOccurred())
+			b, err := f.Generate(true) // Flip to false to see output if goimports fails
+			Expect(err).NotTo(HaveOccurred())
+			if writeToTestData {
+				WriteOutput(b, filepath.Join("testdata", "output", "named_params", "actual.go"))
+			}
+			WriteOutput(b, filepath.Join(baseDir, "fixturesfakes", "fake_named_params.go"))
+			RunBuild(baseDir)
+		})
+	})
+
+	when("generating a fake that deep copies its arguments", func() {
+		it("succeeds", func() {
+			initModuleFunc()
+			copyFileFunc("deep_copy.go")
+			f, err := generator.NewFake(generator.InterfaceOrFunction, "DeepCopied", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeDeepCopied", "fixturesfakes", baseDir, generator.WithDeepCopyArgs())
+			Expect(err).NotTo(HaveOccurred())
+			b, err := f.Generate(true) // Flip to false to see output if goimports fails
+			Expect(err).NotTo(HaveOccurred())
+			if writeToTestData {
+				WriteOutput(b, filepath.Join("testdata", "output", "deep_copy", "actual.go"))
+			}
+			Expect(string(b)).To(ContainSubstring("arg2Copy, _ := fake.deepCopy(arg2).(*fixtures.Settings)"))
+			Expect(string(b)).To(ContainSubstring("}{arg1, arg2Copy})"))
+			WriteOutput(b, filepath.Join(baseDir, "fixturesfakes", "fake_deep_copied.go"))
+			RunBuild(baseDir)
+		})
+	})
+
+	when("generating a strict fake", func() {
+		it("succeeds", func() {
+			initModuleFunc()
+			copyFileFunc("strict.go")
+			f, err := generator.NewFake(generator.InterfaceOrFunction, "Strict", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeStrict", "fixturesfakes", baseDir, generator.WithStrict())
+			Expect(err).NotTo(HaveOccurred())
+			b, err := f.Generate(true) // Flip to false to see output if goimports fails
+			Expect(err).NotTo(HaveOccurred())
+			if writeToTestData {
+				WriteOutput(b, filepath.Join("testdata", "output", "strict", "actual.go"))
+			}
+			Expect(string(b)).To(ContainSubstring(`fake.failUnstubbed("Lookup", []interface{}{arg1})`))
+			Expect(string(b)).To(ContainSubstring(`fake.failUnstubbed("Forget", []interface{}{arg1})`))
+			WriteOutput(b, filepath.Join(baseDir, "fixturesfakes", "fake_strict.go"))
+			RunBuild(baseDir)
+		})
+	})
+
+	when("generating a fake with expectations", func() {
+		it("succeeds", func() {
+			initModuleFunc()
+			copyFileFunc("expectations.go")
+			f, err := generator.NewFake(generator.InterfaceOrFunction, "Expected", "github.com/maxbrunsfeld/counterfeiter/fixtures", "FakeExpected", "fixturesfakes", baseDir, generator.WithExpectations())
+			Expect(err).NotTo(HaveOccurred())
+			b, err := f.Generate(true) // Flip to false to see output if goimports fails
+			Expect(err).NotTo(HaveOccurred())
+			if writeToTestData {
+				WriteOutput(b, filepath.Join("testdata", "output", "expectations", "actual.go"))
+			}
+			Expect(string(b)).To(ContainSubstring("func (fake *FakeExpected) ExpectDoThings(arg1 string, arg2 uint64) *FakeExpectedDoThingsExpectation {"))
+			Expect(string(b)).To(ContainSubstring("func (fake *FakeExpected) Verify(t interface {"))
+			WriteOutput(b, filepath.Join(baseDir, "fixturesfakes", "fake_expected.go"))
+			RunBuild(baseDir)
+		})
+	})
+
 	when(name, func() {
 		t := func(interfaceName string, filename string, subDir string, files ...string) {
 			when("working with "+filename, func() {
@@ -1,9 +1,9 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"go/format"
 	"io"
 	"io/ioutil"
 	"log"
@@ -11,9 +11,10 @@ import (
 	"path/filepath"
 	"runtime/debug"
 	"runtime/pprof"
+	"strings"
 
 	"github.com/maxbrunsfeld/counterfeiter/arguments"
-	"github.com/maxbrunsfeld/counterfeiter/generator"
+	"github.com/maxbrunsfeld/counterfeiter/counterfeiter"
 )
 
 func main() {
@@ -42,74 +43,125 @@ func main() {
 	if !isDebug() {
 		log.SetOutput(ioutil.Discard)
 	}
-	flag.Parse()
-	args := flag.Args()
+	var flags arguments.Flags
+	fs := arguments.NewFlagSet(&flags, flag.ExitOnError)
+	fs.Parse(os.Args[1:])
+	args := fs.Args()
+
+	switch flags.OutputFormat {
+	case "text":
+	case "json":
+		jsonOutput = true
+	default:
+		fail("Unknown output format %q, expected text or json", flags.OutputFormat)
+	}
 
-	if len(args) < 1 {
+	opts := counterfeiter.Options{Dir: cwd(), Check: flags.Check}
+	switch {
+	case flags.Config != "":
+		if len(args) > 0 {
+			fail("-config does not take arguments, but was given: %s", strings.Join(args, " "))
+		}
+		opts.Manifest = flags.Config
+	case len(args) < 1:
 		fail("%s", usage)
-		return
+	case args[0] == "generate" || args[0] == "verify":
+		opts.Packages = args[1:]
+		if len(opts.Packages) == 0 {
+			opts.Packages = []string{"./..."}
+		}
+		opts.Check = args[0] == "verify"
+	default:
+		opts.Args = os.Args[1:]
 	}
-
-	argumentParser := arguments.NewArgumentParser(
-		fail,
-		cwd,
-		filepath.EvalSymlinks,
-		os.Stat,
-	)
-	parsedArgs := argumentParser.ParseArguments(args...)
-	generate(cwd(), parsedArgs)
+	generate(opts, any(args, "-"))
 }
 
 func isDebug() bool {
 	return os.Getenv("COUNTERFEITER_DEBUG") != ""
 }
 
-func generate(workingDir string, args arguments.ParsedArguments) {
-	reportStarting(args.PrintToStdOut, args.OutputPath, args.FakeImplName)
-
-	b, err := doGenerate(workingDir, args)
+// generate generates the fakes described by opts, reporting each file in the
+// output format as it is done. When checking, it fails if any of the files on
+// disk are out of date.
+func generate(opts counterfeiter.Options, printToStdOut bool) {
+	opts.OnResult = func(result counterfeiter.Result) {
+		printResult(result, opts.Check, printToStdOut)
+	}
+	results, err := counterfeiter.Generate(context.Background(), opts)
 	if err != nil {
+		if _, ok := err.(*counterfeiter.Error); ok && jsonOutput {
+			os.Exit(1) // already reported with the result of the file
+		}
 		fail("%v", err)
 	}
 
-	printCode(string(b), args.OutputPath, args.PrintToStdOut)
-	reportDoneSimple(args.PrintToStdOut)
+	var stale []string
+	for i := range results {
+		if opts.Check && results[i].Changed {
+			stale = append(stale, results[i].FakeName)
+		}
+	}
+	switch {
+	case len(stale) == 0:
+	case len(opts.Args) > 0:
+		fail("`%s` is out of date", stale[len(stale)-1])
+	default:
+		fail("Some fakes are out of date, run `counterfeiter generate` to update them")
+	}
 }
 
-func doGenerate(workingDir string, args arguments.ParsedArguments) ([]byte, error) {
-	mode := generator.InterfaceOrFunction
-	if args.GenerateInterfaceAndShimFromPackageDirectory {
-		mode = generator.Package
+// printResult prints the result for a file in the output format. In the text
+// format a failure is printed afterwards by fail.
+func printResult(result counterfeiter.Result, check, printToStdOut bool) {
+	if jsonOutput {
+		reportResult(printToStdOut, result)
+		if printToStdOut && result.Error == nil {
+			fmt.Println(string(result.Code))
+		}
+		return
 	}
-	f, err := generator.NewFake(mode, args.InterfaceName, args.PackagePath, args.FakeImplName, args.DestinationPackageName, workingDir)
-	if err != nil {
-		return nil, err
+	if result.OutputPath == "" {
+		return
+	}
+
+	if !check {
+		reportStarting(printToStdOut, result.OutputPath, result.FakeName)
+		if result.Error != nil {
+			return
+		}
+		if printToStdOut {
+			fmt.Println(string(result.Code))
+		}
+		reportDoneSimple(printToStdOut)
+		return
 	}
-	return f.Generate(true)
-}
 
-func printCode(code, outputPath string, printToStdOut bool) {
-	newCode, err := format.Source([]byte(code))
+	rel, err := filepath.Rel(cwd(), result.OutputPath)
 	if err != nil {
 		fail("%v", err)
 	}
+	fmt.Printf("Checking `%s` in `%s`... ", result.FakeName, rel)
+	switch {
+	case result.Error != nil:
+	case !result.Changed:
+		fmt.Println("Up to date")
+	case result.New:
+		fmt.Println("Missing")
+		fmt.Print(result.Diff)
+	default:
+		fmt.Println("Out of date")
+		fmt.Print(result.Diff)
+	}
+}
 
-	code = string(newCode)
-
-	if printToStdOut {
-		fmt.Println(code)
-	} else {
-		os.MkdirAll(filepath.Dir(outputPath), 0777)
-		file, err := os.Create(outputPath)
-		if err != nil {
-			fail("Couldn't create fake file - %v", err)
-		}
-
-		_, err = file.WriteString(code)
-		if err != nil {
-			fail("Couldn't write to fake file - %v", err)
+func any(slice []string, needle string) bool {
+	for _, str := range slice {
+		if str == needle {
+			return true
 		}
 	}
+	return false
 }
 
 func reportStarting(printToStdOut bool, outputPath, fakeName string) {
@@ -168,6 +220,10 @@ func cwd() string {
 }
 
 func fail(s string, args ...interface{}) {
+	if jsonOutput {
+		reportResult(false, counterfeiter.Result{Error: counterfeiter.NewError(fmt.Errorf(s, args...))})
+		os.Exit(1)
+	}
 	fmt.Printf("\n"+s+"\n", args...)
 	os.Exit(1)
 }
@@ -175,9 +231,35 @@ func fail(s string, args ...interface{}) {
 var usage = `
 USAGE
 	counterfeiter
-		[-o <output-path>] [-p] [--fake-name <fake-name>]
+		[-o <output-path>] [-p [--shim-types <types>] [--vars <names>]
+		[--exclude-vars <names>]] [--fake-name <fake-name>]
+		[--param-names] [--deep-copy-args] [--strict] [--expectations]
+		[--sync-hooks] [--record-calls] [--inject-errors]
+		[--call-recorder] [--returns-when] [--returns-sequence] [--reset]
+		[--delegate] [--context-errors] [--template <path>]
+		[--style <style>] [--check] [--output-format <format>]
 		[<source-path>] <interface> [-]
 
+	counterfeiter
+		[-o <output-path>] [--param-names] [--deep-copy-args] [--strict]
+		[--expectations] [--sync-hooks] [--record-calls] [--inject-errors]
+		[--call-recorder] [--returns-when] [--returns-sequence] [--reset]
+		[--delegate] [--context-errors] [--check]
+		[--output-format <format>]
+		<source-path> (<interface>... | -all [-match <regex>]) [-]
+
+	counterfeiter
+		--from-struct <package>.<struct> [--adapter] [-o <output-path>]
+		[--fake-name <fake-name>] [--param-names] [--deep-copy-args]
+		[--strict] [--expectations] [--sync-hooks] [--record-calls]
+		[--inject-errors] [--call-recorder] [--returns-when]
+		[--returns-sequence] [--reset] [--delegate] [--context-errors]
+		[--check] <interface> [-]
+
+	counterfeiter generate [<packages>]
+	counterfeiter verify [<packages>]
+	counterfeiter -config <manifest> [--check]
+
 ARGUMENTS
 	source-path
 		Path to the file or directory containing the interface to fake.
@@ -194,9 +276,45 @@ ARGUMENTS
 		# writes "FakeStdInterface" to ./packagefakes/fake_std_interface.go
 		counterfeiter package/subpackage.StdInterface
 
+	A generic interface or function type, such as Repository[T any],
+	is faked by a generic fake, FakeRepository[T any]. To fake a single
+	instance of it instead, give its type arguments.
+
+	example:
+		# writes "FakeRepositoryUser" to ./mypackagefakes/fake_repository_user.go
+		counterfeiter ./mypackage 'Repository[User]'
+
+	Several interfaces of the package at source-path can be faked at
+	once by naming each of them. Each fake is written to a file of its
+	own in the output directory, or all of them to the output file
+	when -o ends in .go.
+
+	example:
+		# writes "FakeReader" and "FakeWriter" to ./mypackagefakes/fakes.go
+		counterfeiter -o ./mypackagefakes/fakes.go ./mypackage Reader Writer
+
 	'-' argument
 		Write code to standard out instead of to a file
 
+	generate
+		Batch mode: find every counterfeiter go:generate directive in
+		<packages> (default "./...") and generate all of the fakes in a
+		single process. Each package containing a target is only loaded
+		once, however many fakes are generated from it.
+
+	example:
+		# generates every fake declared with //go:generate counterfeiter ...
+		counterfeiter generate ./...
+
+	verify
+		Like generate, but only checks that every fake on disk is up to
+		date, as --check does. It exits non-zero after printing a diff for
+		each fake that is missing or out of date.
+
+	example:
+		# fails CI when a fake needs to be regenerated
+		counterfeiter verify ./...
+
 OPTIONS
 	-o
 		Path to the file or directory for the generated fakes.
@@ -214,14 +332,78 @@ OPTIONS
 		will generate an interface and shim implementation from a
 		package in your GOPATH.  Counterfeiter finds the public methods
 		in the package <source-path> and adds those method signatures
-		to the generated interface <interface-name>.
+		to the generated interface <interface-name>. The interface and
+		its shim are written to <package>.go in the <output-path>
+		directory, ${PWD}/<package>shim by default, unless
+		<output-path> is a file ending in .go.
 
 	example:
-		# generates os.go (interface) and osshim.go (shim) in ${PWD}/osshim
+		# generates the interface and its shim in ${PWD}/osshim/os.go
 		counterfeiter -p os
 		# now generate fake in ${PWD}/osshim/os_fake (fake_os.go)
 		go generate osshim/...
 
+	--shim-types
+		In package mode, a comma separated list of exported struct types
+		of the package to also generate an interface and a delegating
+		shim for. The interface is named after the type, the shim wraps
+		a pointer to it, and functions and methods that return a pointer
+		to one of the types return its interface instead.
+
+	example:
+		# os.Open now returns an osshim.File, implemented by osshim.FileShim
+		counterfeiter -p -shim-types File,Process os
+
+	--vars
+		In package mode, a comma separated list of exported variables
+		and constants of the package to also generate accessor methods
+		for, or * for all of them. Each gets a getter named after it,
+		and each variable a setter named Set<name>, so that code reading
+		or replacing os.Stdout can be given a fake instead. A variable
+		whose type is in --shim-types gets no setter: its getter returns
+		the interface of the shim, but the variable can only hold the
+		type itself.
+
+	--exclude-vars
+		In package mode, a comma separated list of variables and
+		constants to leave out of --vars.
+
+	example:
+		# adds Stdout() *os.File and SetStdout(*os.File), and so on, to
+		# osshim.Os, for every variable and constant of os but Args
+		counterfeiter -p -vars '*' -exclude-vars Args os
+
+	--from-struct
+		Extract an interface named <interface> from the exported
+		methods of a struct type, write it to the current directory
+		and generate a fake for it, as if the interface had been
+		given. The struct is given by its package path and name.
+		With --check or '-', the interface is not written, and the
+		fake is generated for the interface as it would be written.
+
+	example:
+		# writes the interface "Client" to ./client.go and
+		# "FakeClient" to ./mypackagefakes/fake_client.go
+		counterfeiter --from-struct github.com/someone/sdk.Client Client
+
+	--adapter
+		With --from-struct, also generate an adapter which implements
+		the interface by calling the methods of the struct.
+
+	-all
+		Fake every exported interface declared in the package at
+		<source-path>, instead of the ones named after it. Aliases and
+		type constraints are left out.
+
+	-match
+		With -all, only fake the interfaces whose names match the
+		regular expression.
+
+	example:
+		# writes a fake of every interface ending in "Store" to
+		# ./mypackagefakes
+		counterfeiter -all -match 'Store$' ./mypackage
+
 	--fake-name
 		Name of the fake struct to generate. By default, 'Fake' will
 		be prepended to the name of the original interface. (ignored in
@@ -230,4 +412,234 @@ OPTIONS
 	example:
 		# writes "CoolThing" to ./mypackagefakes/cool_thing.go
 		counterfeiter --fake-name CoolThing ./mypackage MyInterface
+
+	-config
+		Generate the fakes declared in a counterfeiter.yaml manifest
+		instead of the one given on the command line. Paths in the
+		manifest are relative to its directory. Each fake accepts
+		package, interface, fake-name, output, package-mode,
+		param-names, deep-copy-args, strict, expectations, sync-hooks,
+		record-calls, inject-errors, call-recorder, returns-when,
+		returns-sequence, reset, delegate, context-errors, template and
+		style keys, which mean the same as the arguments and flags
+		above; options under "defaults" apply to every fake.
+		It takes no other arguments.
+
+	example:
+		# counterfeiter.yaml:
+		#   defaults:
+		#     param-names: true
+		#   fakes:
+		#   - package: ./mypackage
+		#     interface: MyInterface
+		#   - package: io
+		#     interface: Writer
+		#     output: ./iofakes/fake_writer.go
+		counterfeiter -config counterfeiter.yaml
+
+	--template
+		Generate the fake with a Go text/template file instead of the
+		built-in template, or with the interface.tmpl, function.tmpl
+		or package.tmpl file in a directory of templates. The template
+		is executed with the generator.Fake, can call the functions in
+		generator.TemplateFuncs, and can include the built-in template
+		as {{template "interface" .}}, {{template "function" .}} or
+		{{template "package" .}}. The manifest accepts it as template.
+
+	example:
+		# adds the constructors in fake.tmpl to every fake
+		counterfeiter --template ./templates/fake.tmpl ./mypackage MyInterface
+
+	--style
+		Generate a test double in the style of another mocking library
+		instead of a counterfeiter fake: testify embeds mock.Mock from
+		github.com/stretchr/testify/mock, and gomock generates a double
+		driven by a gomock.Controller from go.uber.org/mock/gomock,
+		with an EXPECT() recorder. Only interfaces can be generated in
+		another style. The manifest accepts it as style.
+
+	example:
+		# writes a testify double to ./mypackagefakes/fake_my_interface.go
+		counterfeiter --style testify ./mypackage MyInterface
+
+	--check
+		Generate the fake in memory and compare it with the file at the
+		output path instead of writing it. If the file is missing or out
+		of date, a unified diff is printed and counterfeiter exits
+		non-zero.
+
+	example:
+		# fails when ./mypackagefakes/fake_my_interface.go is stale
+		counterfeiter --check ./mypackage MyInterface
+
+	--param-names
+		Name the parameters and results of the generated methods (and of
+		the XArgsForCall and XReturns helpers) after the ones in the
+		interface, instead of arg1..argN and result1..resultN. Names that
+		are blank, missing or would clash with the generated code fall
+		back to argN and resultN.
+
+	example:
+		# DoThings(name string, count uint64) keeps "name" and "count"
+		counterfeiter --param-names ./mypackage MyInterface
+
+	--deep-copy-args
+		Record a deep copy of every argument that holds maps, slices,
+		arrays or pointers, so that XArgsForCall returns the arguments
+		as they were at the time of the call even when the caller
+		changes them afterwards. Interfaces, functions, channels and
+		the unexported fields of structs are recorded as they are.
+		(ignored in -p mode)
+
+	example:
+		# SaveArgsForCall returns a copy of the *Settings passed to Save
+		counterfeiter --deep-copy-args ./mypackage MyInterface
+
+	--strict
+		Generate a fake that fails a call to a method when nothing is
+		stubbed for it with XStub, XReturns, XReturnsOnCall or a
+		matching XReturnsWhen rule, instead of returning zero values.
+		A method without results only needs XStub. It panics, unless
+		it was given a test with SetTB(t), which it fails with
+		t.Fatalf. Methods passed to AllowUnstubbed keep returning zero
+		values. (ignored in -p mode)
+
+	example:
+		# fake.Lookup("key") panics until fake.LookupReturns is called
+		counterfeiter --strict ./mypackage MyInterface
+
+	--expectations
+		Also generate an ExpectX method for every method X of the
+		interface, which declares a call the fake expects, with
+		Times(n) and Return(...) to say how often and with what
+		results, and Verify(t), which reports the missing and extra
+		calls, and every call that matches no expectation, including
+		the calls to methods without expectations. VerifyOnCleanup(t)
+		does so when the test is cleaned up.
+		(ignored for functions and in -p mode)
+
+	example:
+		# fake.ExpectDoThings("stuff", 5).Times(2).Return(3, nil)
+		counterfeiter --expectations ./mypackage MyInterface
+
+	--sync-hooks
+		Also generate XBlockUntil(ch), which holds the calls to X until
+		ch is released, XWaitForCalls(n, timeout), which waits for X to
+		have been called n times, and XCallEvents(buffer), which
+		returns a channel that receives the index of every later call
+		to X. A call never waits for room in that channel: an event
+		that does not fit in the buffer is dropped. (ignored in -p
+		mode)
+
+	example:
+		# fake.DoThingsBlockUntil(release) holds DoThings until release is closed
+		counterfeiter --sync-hooks ./mypackage MyInterface
+
+	--record-calls
+		Also keep a record of each call to the fake of an interface,
+		with its results, when it started, how long it took and the
+		value it panicked with, which it panics again with. Generates
+		XCallRecords(), which returns the records of the calls to X,
+		and XResultsForCall(i), which returns the results of a call.
+		(ignored for functions and in -p mode)
+
+	example:
+		# fake.DoThingsCallRecords()[0].Duration is how long the first call took
+		counterfeiter --record-calls ./mypackage MyInterface
+
+	--inject-errors
+		Also generate XFailsWith(err) and XFailsOnCall(i, err) for every
+		method X of the interface that returns an error, and
+		FailAll(err) and FailRandomly(seed, rate, err), which make all
+		of them fail. A call stubbed with XStub, XReturnsOnCall,
+		XReturnsSequence or XReturnsWhen returns what it is stubbed
+		with; the injected errors take precedence over XReturns and the
+		delegate. (ignored for functions and in -p mode)
+
+	example:
+		# fake.FailRandomly(42, 0.1, errors.New("flaky")) fails one call in ten
+		counterfeiter --inject-errors ./mypackage MyInterface
+
+	--call-recorder
+		Also generate SetCallRecorder(recorder), which passes every
+		call to the fake on to recorder, such as a callorder.Sequencer
+		that records the order of the calls to several fakes. The
+		recorder is called after the fake has recorded the call, and may
+		read the fake back. (ignored in -p mode)
+
+	example:
+		# callorder.NewSequencer(fakeFile, fakeWriter) records the calls to both fakes
+		counterfeiter --call-recorder ./mypackage MyInterface
+
+	--returns-when
+		Also generate XReturnsWhen(args..., results...),
+		XReturnsWhenMatches(match, results...) and XReturnsWhenStrict()
+		for every method X with parameters and results, which stub
+		the results of X for the calls whose arguments are equal to
+		args, or that match returns true for. The rule added last wins,
+		and a call that matches no rule returns what XReturns set,
+		or panics after XReturnsWhenStrict. (ignored in -p mode)
+
+	example:
+		# fake.DoThingsReturnsWhen("stuff", 5, 3, nil) makes DoThings("stuff", 5) return 3
+		counterfeiter --returns-when ./mypackage MyInterface
+
+	--returns-sequence
+		Also generate XReturnsSequence() for every method X with
+		results, which returns a sequence that the results of the calls
+		to X that follow are added to with Then(results...), one call
+		at a time. Once they run out, the sequence repeats the last
+		results, or starts over, returns zero values or panics after
+		Cycle(), ZeroValues() or Fail(). (ignored in -p mode)
+
+	example:
+		# fake.DoThingsReturnsSequence().Then(1, nil).Then(2, nil).Fail()
+		counterfeiter --returns-sequence ./mypackage MyInterface
+
+	--reset
+		Also generate Reset(), which clears the calls the fake recorded
+		and everything it was stubbed with, ResetCalls(), which only
+		clears the calls, and XReset() for every method X. A fake of an
+		interface with a Reset method of its own leaves out Reset and
+		ResetCalls. (ignored in -p mode)
+
+	example:
+		# t.Cleanup(fake.Reset) lets several subtests share the fake
+		counterfeiter --reset ./mypackage MyInterface
+
+	--delegate
+		Also generate NewXWithDelegate(delegate), which returns a fake
+		that calls delegate, a real implementation of the interface,
+		for the methods that nothing is stubbed for. Only the fakes of
+		exported interfaces have it. (ignored in -p mode)
+
+	example:
+		# fakes.NewFakeMyInterfaceWithDelegate(real) records the calls to real
+		counterfeiter --delegate ./mypackage MyInterface
+
+	--context-errors
+		Also generate ReturnContextErrors(enabled) for a fake with
+		methods whose first parameter is a context.Context and whose
+		last result is an error. Once enabled, such a method returns
+		zero values and ctx.Err() for a context that is done, without
+		calling its stub. (ignored in -p mode)
+
+	example:
+		# fake.ReturnContextErrors(true) makes Fetch(ctx) fail once ctx is cancelled
+		counterfeiter --context-errors ./mypackage MyInterface
+
+	--output-format
+		The format in which to report what was generated, "text" (the
+		default) or "json". With json, a line of JSON is printed for
+		every file instead of the usual messages, with the target
+		package, interface, fake name, output path, imports, methods,
+		whether the file changed and how long it took. A failure is
+		reported in the "error" of a record, with the errors of the
+		package loader attached. When the code is printed to standard
+		out with '-', the records are printed to standard error. It
+		must come before "generate" or "verify", and applies to every
+		fake they generate.
+
+	example:
+		counterfeiter --output-format=json generate ./...
 `
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/maxbrunsfeld/counterfeiter/counterfeiter"
+)
+
+// jsonOutput is set by --output-format=json, which reports a Result for every
+// file instead of the usual progress messages.
+var jsonOutput bool
+
+// reportResult prints result as a line of JSON, to stderr when the code is
+// printed to stdout.
+func reportResult(printToStdOut bool, result counterfeiter.Result) {
+	var writer io.Writer
+	if printToStdOut {
+		writer = os.Stderr
+	} else {
+		writer = os.Stdout
+	}
+
+	if err := json.NewEncoder(writer).Encode(result); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}